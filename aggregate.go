@@ -69,6 +69,14 @@ func Count(col string) Aggregate {
 		arg: col,
 	}
 }
+
+func Sum(col string) Aggregate {
+	return Aggregate{
+		fn:  "SUM",
+		arg: col,
+	}
+}
+
 func Min(col string) Aggregate {
 	return Aggregate{
 		fn:  "MIN",
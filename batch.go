@@ -0,0 +1,175 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"orm/internal/errs"
+	"orm/model"
+)
+
+// BatchError 是 ExecBatch 某一批插入失败的错误
+type BatchError struct {
+	// Chunk 是失败的批次，从 0 开始
+	Chunk int
+	// Start 和 End 是这一批在 Values 里面的下标，左闭右开
+	Start int
+	End   int
+	// RowsAffected 是之前成功的批次插入的行数，在事务里面执行的时候回滚了，所以是 0
+	RowsAffected int64
+	Err          error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("orm: 第 %d 批插入失败，行 [%d, %d)：%v", e.Chunk, e.Start, e.End, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchSize 设置 ExecBatch 每条语句最多插入的行数。
+// 不设置或者行数乘以列数超过了方言的参数个数上限，会按照上限来切分
+func (i *Inserter[T]) BatchSize(n int) *Inserter[T] {
+	i.batchSize = n
+	return i
+}
+
+// BatchInTx 让 ExecBatch 在一个事务里面执行，任何一批失败都会回滚。
+// Inserter 本身就是用 Tx 创建的时候直接使用那个事务
+func (i *Inserter[T]) BatchInTx(opts *sql.TxOptions) *Inserter[T] {
+	i.batchTx = true
+	i.txOpts = opts
+	return i
+}
+
+// ExecBatch 把 Values 切分成多批插入，返回的 RowsAffected 是所有批次的总和，
+// LastInsertId 是最后一批的。某一批失败的时候返回 *BatchError，后面的批次不会再执行
+func (i *Inserter[T]) ExecBatch(ctx context.Context) Result {
+	if len(i.val) == 0 {
+		return Result{err: errs.ErrInsertZeroRow}
+	}
+	size, err := i.chunkSize()
+	if err != nil {
+		return Result{err: err}
+	}
+	db, ok := i.sess.(*DB)
+	if !i.batchTx || !ok {
+		return i.execChunks(ctx, i.sess, size)
+	}
+	tx, err := db.BeginTx(ctx, i.txOpts)
+	if err != nil {
+		return Result{err: err}
+	}
+	res := i.execChunks(ctx, tx, size)
+	if res.err != nil {
+		if be, ok := res.err.(*BatchError); ok {
+			be.RowsAffected = 0
+		}
+		_ = tx.Rollback()
+		return Result{err: res.err}
+	}
+	if err = tx.Commit(); err != nil {
+		return Result{err: err}
+	}
+	return res
+}
+
+func (i *Inserter[T]) chunkSize() (int, error) {
+	m, err := i.r.Get(new(T))
+	if err != nil {
+		return 0, err
+	}
+	cols := len(i.columns)
+	if cols == 0 {
+		cols = len(m.FieldArr)
+	} else if i.tenantColumn(m) {
+		// 指定的列里面没有租户字段的时候会自动加上
+		cols++
+	}
+	// upsert 里面的参数也要算进去，这里按照每一行都有这么多参数保守估计
+	if i.onDuplicateKey != nil {
+		cols += len(i.onDuplicateKey.assigns)
+	}
+	limit := i.dialect.maxPlaceholders() / cols
+	if i.batchSize > 0 && i.batchSize < limit {
+		return i.batchSize, nil
+	}
+	return limit, nil
+}
+
+// tenantColumn 判断 Columns 里面没有租户字段，插入的时候需要自动加上
+func (i *Inserter[T]) tenantColumn(m *model.Model) bool {
+	if i.unscoped || i.tenant == nil {
+		return false
+	}
+	if _, ok := m.Fields[i.tenant.field]; !ok {
+		return false
+	}
+	for _, c := range i.columns {
+		if c == i.tenant.field {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *Inserter[T]) execChunks(ctx context.Context, sess Session, size int) Result {
+	res := &batchResult{}
+	for start, chunk := 0, 0; start < len(i.val); start, chunk = start+size, chunk+1 {
+		end := start + size
+		if end > len(i.val) {
+			end = len(i.val)
+		}
+		ins := &Inserter[T]{
+			builder:        i.builder.clone(),
+			val:            i.val[start:end],
+			columns:        i.columns,
+			onDuplicateKey: i.onDuplicateKey,
+			tableName:      i.tableName,
+			ignore:         i.ignore,
+		}
+		ins.sess, ins.core = sess, sess.getCore()
+		r := ins.Exec(ctx)
+		if r.err == nil {
+			r.err = res.add(r.res)
+		}
+		if r.err != nil {
+			return Result{err: &BatchError{
+				Chunk:        chunk,
+				Start:        start,
+				End:          end,
+				RowsAffected: res.rows,
+				Err:          r.err,
+			}}
+		}
+	}
+	return Result{res: res}
+}
+
+// batchResult 汇总每一批的结果
+type batchResult struct {
+	rows   int64
+	lastId int64
+}
+
+func (b *batchResult) add(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	b.rows += rows
+	// 有些驱动不支持 LastInsertId，不影响插入的结果
+	if id, err := res.LastInsertId(); err == nil {
+		b.lastId = id
+	}
+	return nil
+}
+
+func (b *batchResult) LastInsertId() (int64, error) {
+	return b.lastId, nil
+}
+
+func (b *batchResult) RowsAffected() (int64, error) {
+	return b.rows, nil
+}
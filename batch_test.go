@@ -0,0 +1,299 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestInserter_Ignore(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dialect   Dialect
+		i         func(db *DB) *Inserter[TestModel]
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(&TestModel{Id: 1}).Ignore()
+			},
+			wantQuery: &Query{
+				SQL:  "INSERT IGNORE INTO `test_model`(`id`) VALUES (?);",
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(&TestModel{Id: 1}).Ignore()
+			},
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model`(`id`) VALUES (?) ON CONFLICT DO NOTHING;",
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "postgres",
+			dialect: DialectPostgreSQL,
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(&TestModel{Id: 1}).Ignore()
+			},
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model"("id") VALUES (?) ON CONFLICT DO NOTHING;`,
+				Args: []any{int64(1)},
+			},
+		},
+		{
+			name:    "with upsert",
+			dialect: DialectMySQL,
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Values(&TestModel{Id: 1}).Ignore().
+					OnDuplicateKey().Update(Assign("Age", 18))
+			},
+			wantErr: errs.ErrIgnoreWithUpsert,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			q, err := tc.i(db).Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestInserter_ExecBatch(t *testing.T) {
+	rows := func(n int) []*TestModel {
+		res := make([]*TestModel, 0, n)
+		for i := 1; i <= n; i++ {
+			res = append(res, &TestModel{Id: int64(i)})
+		}
+		return res
+	}
+	testCases := []struct {
+		name     string
+		mock     func(mock sqlmock.Sqlmock)
+		i        func(db *DB) *Inserter[TestModel]
+		affected int64
+		lastId   int64
+		wantErr  error
+	}{
+		{
+			name: "chunks",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `test_model`\\(`id`\\) VALUES \\(\\?\\),\\(\\?\\);").
+					WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectExec("INSERT INTO `test_model`\\(`id`\\) VALUES \\(\\?\\),\\(\\?\\);").
+					WithArgs(3, 4).WillReturnResult(sqlmock.NewResult(4, 2))
+				mock.ExpectExec("INSERT INTO `test_model`\\(`id`\\) VALUES \\(\\?\\);").
+					WithArgs(5).WillReturnResult(sqlmock.NewResult(5, 1))
+			},
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(rows(5)...).BatchSize(2)
+			},
+			affected: 5,
+			lastId:   5,
+		},
+		{
+			name: "chunk failed",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO .*").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectExec("INSERT INTO .*").WithArgs(3, 4).WillReturnError(errors.New("db error"))
+			},
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(rows(5)...).BatchSize(2)
+			},
+			wantErr: &BatchError{Chunk: 1, Start: 2, End: 4, RowsAffected: 2, Err: errors.New("db error")},
+		},
+		{
+			name: "tx",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO .*").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectExec("INSERT INTO .*").WithArgs(3).WillReturnResult(sqlmock.NewResult(3, 1))
+				mock.ExpectCommit()
+			},
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(rows(3)...).BatchSize(2).BatchInTx(nil)
+			},
+			affected: 3,
+			lastId:   3,
+		},
+		{
+			name: "tx rollback",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO .*").WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectExec("INSERT INTO .*").WithArgs(3).WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).Columns("Id").Values(rows(3)...).BatchSize(2).BatchInTx(nil)
+			},
+			wantErr: &BatchError{Chunk: 1, Start: 2, End: 3, Err: errors.New("db error")},
+		},
+		{
+			name: "no row",
+			mock: func(mock sqlmock.Sqlmock) {},
+			i: func(db *DB) *Inserter[TestModel] {
+				return NewInserter[TestModel](db).BatchSize(2)
+			},
+			wantErr: errs.ErrInsertZeroRow,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+
+			res := tc.i(db).ExecBatch(context.Background())
+			assert.Equal(t, tc.wantErr, res.Err())
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if res.Err() != nil {
+				return
+			}
+			affected, err := res.RowsAffected()
+			require.NoError(t, err)
+			assert.Equal(t, tc.affected, affected)
+			lastId, err := res.LastInsertId()
+			require.NoError(t, err)
+			assert.Equal(t, tc.lastId, lastId)
+		})
+	}
+}
+
+func TestInserter_chunkSize(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name string
+		i    *Inserter[TestModel]
+		want int
+	}{
+		{
+			name: "default",
+			i:    NewInserter[TestModel](db),
+			want: 65535 / 4,
+		},
+		{
+			name: "columns",
+			i:    NewInserter[TestModel](db).Columns("Id", "Age"),
+			want: 65535 / 2,
+		},
+		{
+			name: "batch size",
+			i:    NewInserter[TestModel](db).BatchSize(100),
+			want: 100,
+		},
+		{
+			name: "batch size too large",
+			i:    NewInserter[TestModel](db).BatchSize(100000),
+			want: 65535 / 4,
+		},
+		{
+			name: "upsert",
+			i:    NewInserter[TestModel](db).OnDuplicateKey().Update(Assign("Age", 18), C("FirstName")),
+			want: 65535 / 6,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := tc.i.chunkSize()
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, size)
+		})
+	}
+}
+
+func TestInserter_chunkSize_Tenant(t *testing.T) {
+	db := memoryDB(t, DBWithTenant("TenantId", tenantOf))
+	testCases := []struct {
+		name string
+		i    *Inserter[Post]
+		want int
+	}{
+		{
+			name: "default",
+			i:    NewInserter[Post](db),
+			want: 65535 / 4,
+		},
+		{
+			// 自动加上的租户字段也要算进去
+			name: "columns",
+			i:    NewInserter[Post](db).Columns("Id", "Title"),
+			want: 65535 / 3,
+		},
+		{
+			name: "columns with tenant",
+			i:    NewInserter[Post](db).Columns("Id", "TenantId"),
+			want: 65535 / 2,
+		},
+		{
+			name: "unscoped",
+			i:    NewInserter[Post](db).Columns("Id", "Title").Unscoped(),
+			want: 65535 / 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := tc.i.chunkSize()
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, size)
+		})
+	}
+}
+
+func TestInserter_ExecBatch_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:exec_batch.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(TestModel{}.CreateSQL())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	vals := make([]*TestModel, 0, 4)
+	for i, name := range []string{"Tom", "Jerry", "Tim"} {
+		vals = append(vals, &TestModel{Id: int64(i + 1), FirstName: name, LastName: sql.NullString{String: name, Valid: true}})
+	}
+	affected, err := NewInserter[TestModel](db).Values(vals...).BatchSize(2).BatchInTx(nil).
+		ExecBatch(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	// 重复的行被跳过
+	vals = append(vals, &TestModel{Id: 4, FirstName: "Jack", LastName: sql.NullString{String: "Jack", Valid: true}})
+	affected, err = NewInserter[TestModel](db).Values(vals...).BatchSize(3).Ignore().
+		ExecBatch(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// 超过 SQLite 的参数个数上限要切成多条语句
+	vals = make([]*TestModel, 0, 10000)
+	for i := 0; i < cap(vals); i++ {
+		vals = append(vals, &TestModel{Id: int64(i + 100), LastName: sql.NullString{Valid: true}})
+	}
+	i := NewInserter[TestModel](db).Values(vals...)
+	size, err := i.chunkSize()
+	require.NoError(t, err)
+	assert.Equal(t, 32766/4, size)
+	affected, err = i.ExecBatch(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10000), affected)
+}
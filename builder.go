@@ -1,7 +1,10 @@
 package orm
 
 import (
+	"context"
 	"orm/internal/errs"
+	"orm/model"
+	"reflect"
 	"strings"
 )
 
@@ -9,6 +12,14 @@ type builder struct {
 	sb   strings.Builder
 	args []any
 	sess Session
+	// ctx 是执行语句的 ctx，Scope 和多租户需要用到，直接调用 Build 的时候是 nil
+	ctx context.Context
+	// unscoped 为 true 的时候不使用 Scope 和多租户隔离
+	unscoped bool
+	hints    []string
+	comments map[string]string
+	// renames 是 Rewriter 替换的表名
+	renames map[string]string
 	core
 }
 
@@ -112,6 +123,70 @@ func (b *builder) buildColumn(col Column) error {
 	}
 }
 
+func (b *builder) buildTable(table TableReference) error {
+	switch t := table.(type) {
+	case nil:
+		b.quote(b.tableName(b.Model.TableName))
+	case Table:
+		m, err := b.r.Get(t.entity)
+		if err != nil {
+			return err
+		}
+		b.quote(b.tableName(m.TableName))
+		if t.alias != "" {
+			b.sb.WriteString(" AS ")
+			b.quote(t.alias)
+		}
+	case Join:
+		b.sb.WriteByte('(')
+		if err := b.buildJoin(t); err != nil {
+			return err
+		}
+		b.sb.WriteByte(')')
+	case SubQuery:
+		return b.buildSubQuery(t)
+	default:
+		return errs.NewErrUnSupportedTable(t)
+	}
+	return nil
+}
+
+// buildJoin 构造 JOIN 本身，不带外面的括号
+func (b *builder) buildJoin(t Join) error {
+	err := b.buildTable(t.left)
+	if err != nil {
+		return err
+	}
+	b.sb.WriteByte(' ')
+	b.sb.WriteString(t.typ)
+	b.sb.WriteByte(' ')
+	err = b.buildTable(t.right)
+	if err != nil {
+		return err
+	}
+	if len(t.using) > 0 {
+		b.sb.WriteString(" USING (")
+		for i, u := range t.using {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			err = b.buildColumn(Column{name: u})
+			if err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	}
+	if len(t.on) > 0 {
+		b.sb.WriteString(" ON ")
+		err = b.buildPredicates(t.on)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *builder) buildOrderBy(order OrderBy) error {
 	if _, ok := b.Model.Fields[order.col]; !ok {
 		return errs.NewErrUnKnownField(order.col)
@@ -141,11 +216,15 @@ func (b *builder) buildExpression(exp Expression) error {
 			b.sb.WriteString(expr.op.String())
 			b.sb.WriteByte(' ')
 		}
-		_, ok = expr.right.(Predicate)
+		right, err := b.predicateValue(expr)
+		if err != nil {
+			return err
+		}
+		_, ok = right.(Predicate)
 		if ok {
 			b.sb.WriteByte('(')
 		}
-		if err := b.buildExpression(expr.right); err != nil {
+		if err := b.buildExpression(right); err != nil {
 			return err
 		}
 		if ok {
@@ -162,9 +241,35 @@ func (b *builder) buildExpression(exp Expression) error {
 	case value:
 		b.sb.WriteByte('?')
 		b.addArg(expr.value)
+	case rowValue:
+		b.sb.WriteByte('(')
+		for i, col := range expr.cols {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildColumn(col); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
+	case values:
+		if len(expr.values) == 0 {
+			return errs.ErrEmptySliceArg
+		}
+		b.sb.WriteByte('(')
+		for i, val := range expr.values {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.sb.WriteByte('?')
+			b.addArg(val)
+		}
+		b.sb.WriteByte(')')
 	case RawExpr:
 		b.sb.WriteString(expr.raw)
 		b.addArg(expr.args...)
+	case JSONExpr, jsonContains:
+		return b.dialect.buildJSON(b, expr)
 	case Aggregate:
 		b.sb.WriteString(expr.fn)
 		b.sb.WriteByte('(')
@@ -174,17 +279,114 @@ func (b *builder) buildExpression(exp Expression) error {
 		}
 		b.sb.WriteByte(')')
 	case SubQuery:
-		b.buildSubQuery(expr)
+		return b.buildSubQuery(expr)
 	case SubqueryExpr:
 		b.sb.WriteString(expr.pred)
 		b.sb.WriteByte(' ')
-		b.buildSubQuery(expr.s)
+		return b.buildSubQuery(expr.s)
 	default:
 		return errs.NewErrUnSupportExpression(expr)
 	}
 	return nil
 }
 
+// predicateValue 和列比较的值要经过字段的 Converter，比如枚举按照标签存成文本
+func (b *builder) predicateValue(p Predicate) (Expression, error) {
+	col, ok := p.left.(Column)
+	if !ok || p.op == opLike {
+		return p.right, nil
+	}
+	fd, ok := b.fieldOf(col)
+	if !ok || fd.Converter == nil {
+		return p.right, nil
+	}
+	switch right := p.right.(type) {
+	case value:
+		val, err := predicateArg(fd, right.value)
+		if err != nil {
+			return nil, err
+		}
+		return value{value: val}, nil
+	case values:
+		vals := make([]any, 0, len(right.values))
+		for _, v := range right.values {
+			val, err := predicateArg(fd, v)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+		return values{values: vals}, nil
+	default:
+		return p.right, nil
+	}
+}
+
+// fieldOf 找到列对应的字段，子查询里面的列不处理
+func (b *builder) fieldOf(col Column) (*model.Field, bool) {
+	switch table := col.table.(type) {
+	case nil:
+		fd, ok := b.Model.Fields[col.name]
+		return fd, ok
+	case Table:
+		m, err := b.r.Get(table.entity)
+		if err != nil {
+			return nil, false
+		}
+		fd, ok := m.Fields[col.name]
+		return fd, ok
+	default:
+		return nil, false
+	}
+}
+
+// predicateArg 只有转换结果稳定的 Converter 才能用在查询条件里面，
+// 加密和序列化的结果不稳定，生成的条件永远匹配不上，所以直接返回错误
+func predicateArg(fd *model.Field, val any) (any, error) {
+	if !needConvert(fd, val) {
+		return val, nil
+	}
+	if _, ok := fd.Converter.(model.DeterministicConverter); !ok {
+		return nil, errs.NewErrUnstablePredicate(fd.GoName)
+	}
+	return convertValue(fd, val)
+}
+
+// needConvert 只转换类型和字段一致的值，已经是数据库里面的值的原样使用，
+// 字段是指针的时候也接受元素类型的值
+func needConvert(fd *model.Field, val any) bool {
+	if fd.Converter == nil || val == nil {
+		return false
+	}
+	typ := reflect.TypeOf(val)
+	return typ == fd.Typ || fd.Typ.Kind() == reflect.Pointer && typ == fd.Typ.Elem()
+}
+
+func convertValue(fd *model.Field, val any) (any, error) {
+	if !needConvert(fd, val) {
+		return val, nil
+	}
+	if typ := reflect.TypeOf(val); typ != fd.Typ {
+		ptr := reflect.New(typ)
+		ptr.Elem().Set(reflect.ValueOf(val))
+		return fd.Value(ptr.Interface())
+	}
+	return fd.Value(val)
+}
+
+// assignValue 赋值的目标是模型的列，值要经过字段的 Converter，表达式原样使用
+func assignValue(fd *model.Field, val any) (Expression, error) {
+	v, ok := valueOf(val).(value)
+	if !ok {
+		return valueOf(val), nil
+	}
+	res, err := convertValue(fd, v.value)
+	if err != nil {
+		return nil, err
+	}
+	return value{value: res}, nil
+}
+
 func (b *builder) addArg(val ...any) {
 	if len(val) == 0 {
 		return
@@ -197,7 +399,7 @@ func (b *builder) addArg(val ...any) {
 }
 
 func (b *builder) buildSubQuery(sub SubQuery) error {
-	query, err := sub.s.Build()
+	query, err := bindContext(b.ctx, sub.s).Build()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,107 @@
+package orm
+
+import "context"
+
+// clone 复制 builder，sb 和 args 都是空的，
+// hints 和 comments 不和 b 共享，修改副本不会影响 b
+func (b *builder) clone() builder {
+	res := builder{
+		sess:     b.sess,
+		ctx:      b.ctx,
+		unscoped: b.unscoped,
+		hints:    clip(b.hints),
+		core:     b.core,
+	}
+	if b.comments != nil {
+		res.comments = make(map[string]string, len(b.comments))
+		for k, v := range b.comments {
+			res.comments[k] = v
+		}
+	}
+	return res
+}
+
+// clip 去掉多余的容量，在副本上 append 的时候会重新分配，不会写到原来的数组里面
+func clip[E any](s []E) []E {
+	return s[:len(s):len(s)]
+}
+
+// Clone 返回一个副本，修改副本不会影响 s。
+// 可以在多个 goroutine 里面共享一个基础的 Selector，每次使用的时候 Clone 之后再加条件
+func (s *Selector[T]) Clone() *Selector[T] {
+	return &Selector[T]{
+		builder:  s.builder.clone(),
+		where:    clip(s.where),
+		columns:  clip(s.columns),
+		groupBy:  clip(s.groupBy),
+		having:   clip(s.having),
+		orderBy:  clip(s.orderBy),
+		offset:   s.offset,
+		limit:    s.limit,
+		table:    s.table,
+		cacheTTL: s.cacheTTL,
+		after:    s.after,
+		index:    s.index,
+	}
+}
+
+func (s *Selector[T]) withContext(ctx context.Context) QueryBuilder {
+	res := s.Clone()
+	res.ctx = ctx
+	return res
+}
+
+// Clone 返回一个副本，Values 传入的实体和 ValuesFrom 传入的查询是共享的
+func (i *Inserter[T]) Clone() *Inserter[T] {
+	return &Inserter[T]{
+		val:            clip(i.val),
+		source:         i.source,
+		builder:        i.builder.clone(),
+		columns:        clip(i.columns),
+		onDuplicateKey: i.onDuplicateKey,
+		tableName:      i.tableName,
+		ignore:         i.ignore,
+		batchSize:      i.batchSize,
+		batchTx:        i.batchTx,
+		txOpts:         i.txOpts,
+	}
+}
+
+func (i *Inserter[T]) withContext(ctx context.Context) QueryBuilder {
+	res := i.Clone()
+	res.ctx = ctx
+	return res
+}
+
+// Clone 返回一个副本，Update 传入的实体是共享的
+func (u *Updater[T]) Clone() *Updater[T] {
+	return &Updater[T]{
+		builder: u.builder.clone(),
+		val:     u.val,
+		assigns: clip(u.assigns),
+		where:   clip(u.where),
+		table:   u.table,
+	}
+}
+
+func (u *Updater[T]) withContext(ctx context.Context) QueryBuilder {
+	res := u.Clone()
+	res.ctx = ctx
+	return res
+}
+
+// Clone 返回一个副本，修改副本不会影响 s
+func (s *Deleter[T]) Clone() *Deleter[T] {
+	return &Deleter[T]{
+		builder:   s.builder.clone(),
+		where:     clip(s.where),
+		tableName: s.tableName,
+		table:     s.table,
+	}
+}
+
+func (s *Deleter[T]) withContext(ctx context.Context) QueryBuilder {
+	res := s.Clone()
+	res.ctx = ctx
+	return res
+}
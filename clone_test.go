@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild_Idempotent(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name string
+		b    QueryBuilder
+	}{
+		{
+			name: "select",
+			b: NewSelector[TestModel](db).Where(C("Age").GT(18)).
+				From(NewSelector[TestModel](db).Where(C("Id").LT(10)).AsSubQuery().As("sub")).
+				Limit(10).Offset(5),
+		},
+		{
+			name: "insert",
+			b:    NewInserter[TestModel](db).Values(&TestModel{Id: 1}, &TestModel{Id: 2}),
+		},
+		{
+			name: "update",
+			b:    NewUpdater[TestModel](db).Set(Assign("Age", 18)).Where(C("Id").EQ(1)),
+		},
+		{
+			name: "delete",
+			b:    NewDeleter[TestModel](db).Where(C("Id").EQ(1)),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			first, err := tc.b.Build()
+			require.NoError(t, err)
+			second, err := tc.b.Build()
+			require.NoError(t, err)
+			assert.Equal(t, first, second)
+		})
+	}
+}
+
+func TestSelector_Clone(t *testing.T) {
+	db := memoryDB(t)
+	base := NewSelector[TestModel](db).Where(C("Age").GT(18)).Hint("MAX_EXECUTION_TIME(100)")
+
+	c := base.Clone().Where(C("Age").GT(18), C("Id").LT(10)).Hint("NO_ICP(test_model)").Limit(1)
+	q, err := c.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(100) NO_ICP(test_model) */ * FROM `test_model` WHERE (`age` > ?) AND (`id` < ?) LIMIT ?;", q.SQL)
+	assert.Equal(t, []any{18, 10, 1}, q.Args)
+
+	q, err = base.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM `test_model` WHERE `age` > ?;", q.SQL)
+	assert.Equal(t, []any{18}, q.Args)
+}
+
+// 用 go test -race 运行，共享的 Selector 只能被读
+func TestClone_Concurrent(t *testing.T) {
+	db := memoryDB(t)
+	base := NewSelector[TestModel](db).Where(C("Age").GT(18)).OrderBy(Asc("Id"))
+	ins := NewInserter[TestModel](db).Values(&TestModel{Id: 1})
+	upd := NewUpdater[TestModel](db).Set(Assign("Age", 18))
+	del := NewDeleter[TestModel](db)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q, err := base.Clone().Where(C("Id").EQ(i)).Comment("req", fmt.Sprint(i)).Build()
+			assert.NoError(t, err)
+			assert.Equal(t, "SELECT * FROM `test_model` WHERE `id` = ? ORDER BY `id` ASC;", q.SQL)
+			assert.Equal(t, []any{i}, q.Args)
+			assert.Equal(t, map[string]string{"req": fmt.Sprint(i)}, q.Comments)
+
+			q, err = base.Build()
+			assert.NoError(t, err)
+			assert.Equal(t, []any{18}, q.Args)
+
+			_, err = ins.Clone().Values(&TestModel{Id: int64(i)}).Build()
+			assert.NoError(t, err)
+			_, err = upd.Clone().Where(C("Id").EQ(i)).Build()
+			assert.NoError(t, err)
+			_, err = del.Clone().Where(C("Id").EQ(i)).Build()
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSelector_ConcurrentGet(t *testing.T) {
+	db, err := Open("sqlite3", "file:clone_get.db?cache=shared&mode=memory",
+		DBWithTenant("TenantId", tenantOf))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec("CREATE TABLE `post`(`id` INTEGER PRIMARY KEY, `tenant_id` INTEGER, `title` TEXT, `deleted` BOOLEAN)")
+	require.NoError(t, err)
+	_, err = db.db.Exec("INSERT INTO `post` VALUES (1, 1, 'a', false), (2, 2, 'b', false)")
+	require.NoError(t, err)
+
+	// 同一个 Selector 在不同的 goroutine 里面使用不同的租户执行
+	s := NewSelector[Post](db).Where(C("Deleted").EQ(false))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(tenant int64) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+			p, err := s.Get(ctx)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tenant, p.TenantId)
+			}
+		}(int64(i%2 + 1))
+	}
+	wg.Wait()
+}
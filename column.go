@@ -64,3 +64,44 @@ func (c Column) GT(val any) Predicate {
 		right: valueOf(val),
 	}
 }
+
+func (c Column) NEQ(val any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNEQ,
+		right: valueOf(val),
+	}
+}
+
+func (c Column) LTEQ(val any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLTEQ,
+		right: valueOf(val),
+	}
+}
+
+func (c Column) GTEQ(val any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opGTEQ,
+		right: valueOf(val),
+	}
+}
+
+// In 构造 c IN (?,?,?)，vals 不能为空
+func (c Column) In(vals ...any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opIN,
+		right: values{values: vals},
+	}
+}
+
+func (c Column) Like(pattern string) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opLike,
+		right: valueOf(pattern),
+	}
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/valuer"
+	"orm/model"
+)
+
+type Profile struct {
+	Id     int64
+	Tags   []string          `orm:"serializer=json"`
+	Attrs  map[string]string `orm:"serializer=json"`
+	Secret string            `orm:"serializer=aes"`
+}
+
+func TestConverter_SQLite(t *testing.T) {
+	model.SetKeyProvider(func() ([]byte, error) {
+		return []byte("0123456789abcdef"), nil
+	})
+	defer model.SetKeyProvider(nil)
+	testCases := []struct {
+		name    string
+		creator valuer.Creator
+	}{
+		{name: "unsafe", creator: valuer.NewUnsafeValue},
+		{name: "reflect", creator: valuer.NewReflectValue},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := Open("sqlite3", "file:converter_"+tc.name+".db?cache=shared&mode=memory")
+			require.NoError(t, err)
+			defer db.Close()
+			db.creator = tc.creator
+			_, err = db.db.Exec("CREATE TABLE `profile`(`id` INTEGER PRIMARY KEY, `tags` TEXT, `attrs` TEXT, `secret` TEXT)")
+			require.NoError(t, err)
+			ctx := context.Background()
+			p := &Profile{Id: 1, Tags: []string{"a", "b"}, Attrs: map[string]string{"k": "v"}, Secret: "password"}
+			require.NoError(t, NewInserter[Profile](db).Values(p).Exec(ctx).Err())
+
+			// 数据库里面是加密之后的值
+			var secret string
+			require.NoError(t, db.db.QueryRow("SELECT `secret` FROM `profile`").Scan(&secret))
+			assert.NotEqual(t, "password", secret)
+
+			tdb := db.Tracked()
+			res, err := NewSelector[Profile](tdb).Where(C("Id").EQ(1)).Get(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, p, res)
+
+			// 加密的结果每次都不一样，但是没有修改的字段不会被更新
+			res.Attrs["k"] = "v2"
+			changes, err := NewUpdater[Profile](tdb).Update(res).Changes()
+			require.NoError(t, err)
+			assert.Equal(t, []Change{{Field: "Attrs", Column: "attrs",
+				Old: map[string]string{"k": "v"}, New: map[string]string{"k": "v2"}}}, changes)
+			require.NoError(t, NewUpdater[Profile](tdb).Update(res).Where(C("Id").EQ(1)).Exec(ctx).Err())
+
+			res, err = NewSelector[Profile](db).Where(C("Id").EQ(1)).Get(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "v2", res.Attrs["k"])
+
+			_, err = db.db.Exec("UPDATE `profile` SET `tags` = 'oops'")
+			require.NoError(t, err)
+			_, err = NewSelector[Profile](db).Where(C("Id").EQ(1)).Get(ctx)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConverter_Assign(t *testing.T) {
+	model.SetKeyProvider(func() ([]byte, error) {
+		return []byte("0123456789abcdef"), nil
+	})
+	defer model.SetKeyProvider(nil)
+	db, err := Open("sqlite3", "file:converter_assign.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	_, err = db.db.Exec("CREATE TABLE `profile`(`id` INTEGER PRIMARY KEY, `tags` TEXT, `attrs` TEXT, `secret` TEXT)")
+	require.NoError(t, err)
+	require.NoError(t, NewCreateTable[Account](db).Exec(ctx).Err())
+	p := &Profile{Id: 1, Tags: []string{"a"}, Secret: "password"}
+	require.NoError(t, NewInserter[Profile](db).Values(p).Exec(ctx).Err())
+	acc := &Account{Id: 1, Name: "Tom", Status: UserActive, Role: UserActive}
+	require.NoError(t, NewInserter[Account](db).Values(acc).Exec(ctx).Err())
+
+	// UPDATE 里面的赋值
+	err = NewUpdater[Profile](db).Set(Assign("Secret", "new"), Assign("Tags", []string{"b"})).
+		Where(C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+	var secret string
+	require.NoError(t, db.db.QueryRow("SELECT `secret` FROM `profile`").Scan(&secret))
+	assert.NotEqual(t, "new", secret)
+	res, err := NewSelector[Profile](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "new", res.Secret)
+	assert.Equal(t, []string{"b"}, res.Tags)
+
+	// 枚举按照标签转换之后才能通过 CHECK
+	err = NewUpdater[Account](db).Set(Assign("Status", UserBlocked), Assign("Role", UserBlocked)).
+		Where(C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+	var status int64
+	var role string
+	require.NoError(t, db.db.QueryRow("SELECT `status`,`role` FROM `account`").Scan(&status, &role))
+	assert.Equal(t, int64(2), status)
+	assert.Equal(t, "blocked", role)
+	err = NewUpdater[Account](db).Set(Assign("Status", UserStatus(0))).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	var enumErr *UnknownEnumError
+	assert.True(t, errors.As(err, &enumErr))
+
+	// upsert 里面的赋值
+	err = NewInserter[Profile](db).Values(p).OnDuplicateKey().ConflictColum("Id").
+		Update(Assign("Secret", "upserted")).Exec(ctx).Err()
+	require.NoError(t, err)
+	res, err = NewSelector[Profile](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "upserted", res.Secret)
+	err = NewInserter[Account](db).Values(acc).OnDuplicateKey().ConflictColum("Id").
+		Update(Assign("Role", UserActive)).Exec(ctx).Err()
+	require.NoError(t, err)
+	require.NoError(t, db.db.QueryRow("SELECT `role` FROM `account`").Scan(&role))
+	assert.Equal(t, "active", role)
+}
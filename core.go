@@ -2,8 +2,10 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"orm/internal/valuer"
 	"orm/model"
+	"reflect"
 )
 
 type core struct {
@@ -12,49 +14,60 @@ type core struct {
 	dialect Dialect
 	mdls    []Middleware
 	Model   *model.Model
+	// tracker 不为 nil 的时候查询出来的实体会被跟踪
+	tracker *tracker
+	scopes  []Scope
+	tenant  *tenant
+	// commenter 返回每条语句都要带上的注释
+	commenter func(ctx context.Context) map[string]string
+	rewriters []Rewriter
+	shadow    *shadow
 }
 
 func get[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
-	var (
-		t   T
-		err error
-	)
-	c.Model, err = c.r.Get(&t)
+	var err error
+	c.Model, err = modelOf[T](c.r)
 	if err != nil {
 		return &QueryResult{
 			Err: err,
 		}
 	}
-	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
-		return getHandler[T](ctx, sess, c, qc)
+	if qc.Model == nil {
+		qc.Model = c.Model
 	}
-	for i := len(c.mdls) - 1; i >= 0; i-- {
-		root = c.mdls[i](root)
+	if sess, c, err = c.route(ctx, sess, qc.Type == "RAW"); err != nil {
+		return &QueryResult{
+			Err: err,
+		}
 	}
-	return root(ctx, qc)
+	qc.ctx, qc.sess, qc.core = ctx, sess, c
+	qc.Builder = bindContext(ctx, qc.Builder)
+	qc.ResultType = reflect.TypeOf(new(T)).Elem()
+	return c.chain(func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getHandler[T](ctx, sess, c, qc)
+	})(ctx, qc)
 }
 
 func getHandler[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
-	q, err := qc.Builder.Build()
+	q, err := qc.Query()
 	if err != nil {
 		return &QueryResult{
 			Err: err,
 		}
 	}
-	rows, err := sess.queryContext(ctx, q.SQL, q.Args...)
+	rows, err := c.queryContext(ctx, sess, q)
 	if err != nil {
 		return &QueryResult{
 			Err: err,
 		}
 	}
+	defer rows.Close()
 	if !rows.Next() {
 		return &QueryResult{
 			Err: ErrNoRows,
 		}
 	}
-	tp := new(T)
-	val := c.creator(c.Model, tp)
-	err = val.SetColumns(rows)
+	tp, err := scanRow[T](c, c.Model, rows)
 	if err != nil {
 		return &QueryResult{
 			Err: err,
@@ -66,8 +79,67 @@ func getHandler[T any](ctx context.Context, sess Session, c core, qc *QueryConte
 	}
 }
 
-func execHandler(ctx context.Context, sess Session, qc *QueryContext) *QueryResult {
-	q, err := qc.Builder.Build()
+func getMulti[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	var err error
+	c.Model, err = modelOf[T](c.r)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	if qc.Model == nil {
+		qc.Model = c.Model
+	}
+	if sess, c, err = c.route(ctx, sess, qc.Type == "RAW"); err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	qc.ctx, qc.sess, qc.core = ctx, sess, c
+	qc.Builder = bindContext(ctx, qc.Builder)
+	qc.ResultType = reflect.TypeOf([]*T{})
+	return c.chain(func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return getMultiHandler[T](ctx, sess, c, qc)
+	})(ctx, qc)
+}
+
+// getMultiHandler 的结果是 *[]*T，和 ResultType 对应
+func getMultiHandler[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	q, err := qc.Query()
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	rows, err := c.queryContext(ctx, sess, q)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	defer rows.Close()
+	res := make([]*T, 0, 8)
+	for rows.Next() {
+		tp, err := scanRow[T](c, c.Model, rows)
+		if err != nil {
+			return &QueryResult{
+				Err: err,
+			}
+		}
+		res = append(res, tp)
+	}
+	if err = rows.Err(); err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	return &QueryResult{
+		Result: &res,
+	}
+}
+
+func execHandler(ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
+	q, err := qc.Query()
 	if err != nil {
 		return &QueryResult{
 			Result: Result{
@@ -75,7 +147,7 @@ func execHandler(ctx context.Context, sess Session, qc *QueryContext) *QueryResu
 			},
 		}
 	}
-	res, err := sess.execContext(ctx, q.SQL, q.Args...)
+	res, err := c.execContext(ctx, sess, q)
 	return &QueryResult{
 		Result: Result{
 			err: err,
@@ -85,11 +157,8 @@ func execHandler(ctx context.Context, sess Session, qc *QueryContext) *QueryResu
 }
 
 func exec[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *QueryResult {
-	var (
-		t   T
-		err error
-	)
-	c.Model, err = c.r.Get(&t)
+	var err error
+	c.Model, err = modelOf[T](c.r)
 	if err != nil {
 		return &QueryResult{
 			Result: Result{
@@ -97,11 +166,45 @@ func exec[T any](ctx context.Context, sess Session, c core, qc *QueryContext) *Q
 			},
 		}
 	}
-	var root Handler = func(ctx context.Context, qc *QueryContext) *QueryResult {
-		return execHandler(ctx, sess, qc)
+	if qc.Model == nil {
+		qc.Model = c.Model
 	}
+	if sess, c, err = c.route(ctx, sess, qc.Type == "RAW"); err != nil {
+		return &QueryResult{
+			Result: Result{
+				err: err,
+			},
+		}
+	}
+	qc.ctx, qc.sess, qc.core = ctx, sess, c
+	qc.Builder = bindContext(ctx, qc.Builder)
+	return c.chain(func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return execHandler(ctx, sess, c, qc)
+	})(ctx, qc)
+}
+
+// chain 把中间件套在 root 外面，第一个中间件在最外层
+func (c core) chain(root Handler) Handler {
 	for i := len(c.mdls) - 1; i >= 0; i-- {
 		root = c.mdls[i](root)
 	}
-	return root(ctx, qc)
+	return root
+}
+
+// queryContext 和 execContext 是真正把查询发给数据库的地方，
+// 在这里把 ? 转换成方言的占位符，中间件看到的始终是 ? 形式的 SQL
+func (c core) queryContext(ctx context.Context, sess Session, q *Query) (*sql.Rows, error) {
+	return sess.queryContext(ctx, c.comment(ctx, q)+c.rebind(q), q.Args...)
+}
+
+func (c core) execContext(ctx context.Context, sess Session, q *Query) (sql.Result, error) {
+	return sess.execContext(ctx, c.comment(ctx, q)+c.rebind(q), q.Args...)
+}
+
+// rebind 没有参数的时候不需要转换，SQL 里面的 ? 只可能是操作符
+func (c core) rebind(q *Query) string {
+	if len(q.Args) == 0 {
+		return q.SQL
+	}
+	return rebind(c.dialect, q.SQL)
 }
@@ -11,7 +11,10 @@ import (
 )
 
 type DB struct {
-	db *sql.DB
+	db        *sql.DB
+	stmtCache *stmtCache
+	// ignoreUnknownColumns 为 true 的时候，结果集里面模型没有的列会被丢弃
+	ignoreUnknownColumns bool
 	core
 }
 
@@ -37,6 +40,11 @@ func OpenDB(db *sql.DB, opts ...DBOptions) (*DB, error) {
 	for _, opt := range opts {
 		opt(res)
 	}
+	// 生成了代码的模型不走反射
+	res.creator = valuer.PreferGenerated(res.creator)
+	if res.ignoreUnknownColumns {
+		res.creator = valuer.IgnoreUnknownColumns(res.creator)
+	}
 	return res, nil
 }
 
@@ -46,6 +54,13 @@ func DBUserReflect() DBOptions {
 	}
 }
 
+// DBIgnoreUnknownColumns 扫描结果的时候忽略模型里面没有的列，默认会返回错误
+func DBIgnoreUnknownColumns() DBOptions {
+	return func(db *DB) {
+		db.ignoreUnknownColumns = true
+	}
+}
+
 func DBWithDialect(dialect Dialect) DBOptions {
 	return func(db *DB) {
 		db.dialect = dialect
@@ -64,13 +79,21 @@ func DBWithMiddleware(mdls ...Middleware) DBOptions {
 	}
 }
 
+// DBWithStmtCache 开启预编译语句缓存，最多缓存 size 条语句
+func DBWithStmtCache(size int) DBOptions {
+	return func(db *DB) {
+		if size > 0 {
+			db.stmtCache = newStmtCache(size)
+		}
+	}
+}
+
 func (db *DB) getCore() core {
 	return db.core
 }
 
 func (db *DB) DoTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) error, opts *sql.TxOptions) (err error) {
 	tx, err := db.BeginTx(ctx, opts)
-	context.WithCancel(context.Background())
 	if err != nil {
 		return err
 	}
@@ -88,20 +111,59 @@ func (db *DB) DoTx(ctx context.Context, fn func(ctx context.Context, tx *Tx) err
 	return err
 }
 
+// BeginTx 压测流量配置了影子库的时候，在影子库上开启事务
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
-	tx, err := db.db.BeginTx(ctx, opts)
+	sess, _, err := db.route(ctx, db, false)
 	if err != nil {
 		return nil, err
 	}
-	return &Tx{tx: tx, db: db}, nil
+	sdb := sess.(*DB)
+	tx, err := sdb.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, db: sdb, main: db}, nil
 }
 
 func (db *DB) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return db.db.QueryContext(ctx, query, args...)
+	if db.stmtCache == nil {
+		return db.db.QueryContext(ctx, query, args...)
+	}
+	entry, err := db.stmtCache.acquire(ctx, db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	// rows 会持有 stmt 的依赖，所以这里直接释放也不会导致 stmt 提前关闭
+	defer db.stmtCache.release(entry)
+	return entry.stmt.QueryContext(ctx, args...)
 }
 
 func (db *DB) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return db.db.ExecContext(ctx, query, args...)
+	if db.stmtCache == nil {
+		return db.db.ExecContext(ctx, query, args...)
+	}
+	entry, err := db.stmtCache.acquire(ctx, db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer db.stmtCache.release(entry)
+	return entry.stmt.ExecContext(ctx, args...)
+}
+
+// StmtCacheStats 返回预编译语句缓存的命中情况，没有开启缓存的时候返回零值
+func (db *DB) StmtCacheStats() StmtCacheStats {
+	if db.stmtCache == nil {
+		return StmtCacheStats{}
+	}
+	return db.stmtCache.stats()
+}
+
+// Close 关闭缓存的预编译语句和底层的 sql.DB
+func (db *DB) Close() error {
+	if db.stmtCache != nil {
+		db.stmtCache.close()
+	}
+	return db.db.Close()
 }
 
 func (db *DB) Wait() error {
@@ -0,0 +1,248 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"orm/internal/errs"
+	"orm/model"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enum 和 UnknownEnumError 定义在 model 里面，这里只是方便使用
+type (
+	Enum             = model.Enum
+	UnknownEnumError = model.UnknownEnumError
+)
+
+// 列的类型先被归类成下面这些，再由方言转换成具体的类型
+const (
+	colBool    = "bool"
+	colInt8    = "int8"
+	colInt16   = "int16"
+	colInt32   = "int32"
+	colInt64   = "int64"
+	colUint8   = "uint8"
+	colUint16  = "uint16"
+	colUint32  = "uint32"
+	colUint64  = "uint64"
+	colFloat32 = "float32"
+	colFloat64 = "float64"
+	colString  = "string"
+	colText    = "text"
+	colBytes   = "bytes"
+	colTime    = "time"
+	colJSON    = "json"
+)
+
+var (
+	nullTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+	}
+	serializerCols = map[string]string{
+		"json": colJSON,
+		"gob":  colBytes,
+		"aes":  colText,
+	}
+)
+
+// CreateTable 根据 T 的模型构造 CREATE TABLE，字段 Id 是主键。
+// 列类型按照 Go 的类型推断，可以用标签 orm:"type=..." 指定；
+// 枚举字段在 MySQL 上按照文本存储的时候是 ENUM(...)，其它情况会加上 CHECK
+type CreateTable[T any] struct {
+	builder
+	ifNotExists bool
+	// entity 不为 nil 的时候使用它的模型，而不是 T 的模型
+	entity any
+}
+
+func NewCreateTable[T any](sess Session) *CreateTable[T] {
+	return &CreateTable[T]{
+		builder: builder{sess: sess, core: sess.getCore()},
+	}
+}
+
+// NewCreateTableOf 用于编译期不知道模型类型的场景，entity 是模型的指针，比如 &User{}
+func NewCreateTableOf(sess Session, entity any) *CreateTable[any] {
+	return &CreateTable[any]{
+		builder: builder{sess: sess, core: sess.getCore()},
+		entity:  entity,
+	}
+}
+
+func (c *CreateTable[T]) withContext(ctx context.Context) QueryBuilder {
+	res := &CreateTable[T]{builder: c.builder.clone(), ifNotExists: c.ifNotExists, entity: c.entity}
+	res.ctx = ctx
+	return res
+}
+
+func (c *CreateTable[T]) IfNotExists() *CreateTable[T] {
+	c.ifNotExists = true
+	return c
+}
+
+func (c *CreateTable[T]) Build() (*Query, error) {
+	c = &CreateTable[T]{builder: c.builder.clone(), ifNotExists: c.ifNotExists, entity: c.entity}
+	var entity any = new(T)
+	if c.entity != nil {
+		entity = c.entity
+	}
+	var err error
+	c.Model, err = c.r.Get(entity)
+	if err != nil {
+		return nil, err
+	}
+	c.sb.WriteString("CREATE TABLE ")
+	if c.ifNotExists {
+		c.sb.WriteString("IF NOT EXISTS ")
+	}
+	c.quote(c.shadowTable(c.Model.TableName))
+	c.sb.WriteByte('(')
+	for i, fd := range c.Model.FieldArr {
+		if i > 0 {
+			c.sb.WriteByte(',')
+		}
+		if err = c.buildColumnDef(fd); err != nil {
+			return nil, err
+		}
+	}
+	c.sb.WriteString(");")
+	return &Query{
+		SQL:  c.sb.String(),
+		Args: c.args,
+	}, nil
+}
+
+func (c *CreateTable[T]) Exec(ctx context.Context) Result {
+	res := exec[T](ctx, c.sess, c.core, &QueryContext{
+		Type:    "CREATE",
+		Builder: c,
+		Model:   c.Model,
+	})
+	if res.Result != nil {
+		return res.Result.(Result)
+	}
+	return Result{
+		err: res.Err,
+	}
+}
+
+func (b *builder) buildColumnDef(fd *model.Field) error {
+	b.quote(fd.ColName)
+	b.sb.WriteByte(' ')
+	kind, nullable, err := columnKind(fd)
+	if err != nil {
+		return err
+	}
+	typ, check := fd.ColType, len(fd.EnumValues) > 0
+	if typ == "" && check {
+		if enum, ok := b.dialect.enumType(fd.EnumValues); ok {
+			typ, check = enum, false
+		}
+	}
+	if typ == "" {
+		typ = b.dialect.columnType(kind)
+	}
+	b.sb.WriteString(typ)
+	if fd.GoName == "Id" {
+		b.sb.WriteString(" PRIMARY KEY")
+	} else if !nullable {
+		b.sb.WriteString(" NOT NULL")
+	}
+	if check {
+		b.sb.WriteString(" CHECK (")
+		b.quote(fd.ColName)
+		b.sb.WriteString(" IN (")
+		b.sb.WriteString(enumLiterals(fd.EnumValues))
+		b.sb.WriteString("))")
+	}
+	return nil
+}
+
+// columnKind 返回列的类型和是否允许 NULL
+func columnKind(fd *model.Field) (string, bool, error) {
+	typ, nullable := fd.Typ, false
+	if typ.Kind() == reflect.Pointer {
+		typ, nullable = typ.Elem(), true
+	}
+	if t, ok := nullTypes[typ]; ok {
+		typ, nullable = t, true
+	}
+	if len(fd.EnumValues) > 0 {
+		if _, ok := fd.EnumValues[0].(string); ok {
+			return colString, nullable, nil
+		}
+	} else if fd.Converter != nil {
+		switch typ.Kind() {
+		case reflect.Map, reflect.Slice:
+			nullable = true
+		}
+		if col, ok := serializerCols[fd.Serializer]; ok {
+			return col, nullable, nil
+		}
+		// 注册的 Converter 转换出来的类型不确定，需要用 type 标签指定
+		return colText, nullable, nil
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return colTime, nullable, nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return colBool, nullable, nil
+	case reflect.Int8:
+		return colInt8, nullable, nil
+	case reflect.Int16:
+		return colInt16, nullable, nil
+	case reflect.Int32:
+		return colInt32, nullable, nil
+	case reflect.Int, reflect.Int64:
+		return colInt64, nullable, nil
+	case reflect.Uint8:
+		return colUint8, nullable, nil
+	case reflect.Uint16:
+		return colUint16, nullable, nil
+	case reflect.Uint32:
+		return colUint32, nullable, nil
+	case reflect.Uint, reflect.Uint64:
+		return colUint64, nullable, nil
+	case reflect.Float32:
+		return colFloat32, nullable, nil
+	case reflect.Float64:
+		return colFloat64, nullable, nil
+	case reflect.String:
+		return colString, nullable, nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return colBytes, true, nil
+		}
+	}
+	return "", false, errs.NewErrUnsupportedColumnType(fd.Typ)
+}
+
+// enumLiterals DDL 里面不能使用占位符，所以直接写值
+func enumLiterals(vals []any) string {
+	var sb strings.Builder
+	for i, val := range vals {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		switch v := val.(type) {
+		case int64:
+			sb.WriteString(strconv.FormatInt(v, 10))
+		case string:
+			sb.WriteByte('\'')
+			sb.WriteString(strings.ReplaceAll(v, "'", "''"))
+			sb.WriteByte('\'')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type UserStatus int8
+
+const (
+	UserActive UserStatus = iota + 1
+	UserBlocked
+)
+
+func (s UserStatus) String() string {
+	switch s {
+	case UserActive:
+		return "active"
+	case UserBlocked:
+		return "blocked"
+	default:
+		return "unknown"
+	}
+}
+
+func (s UserStatus) EnumValues() []any {
+	return []any{UserActive, UserBlocked}
+}
+
+type Account struct {
+	Id        int64
+	Name      string
+	Status    UserStatus
+	Role      UserStatus `orm:"enum=string"`
+	Score     *float64
+	Nick      sql.NullString
+	Tags      []string `orm:"serializer=json"`
+	Avatar    []byte
+	CreatedAt time.Time
+	Remark    string `orm:"type=VARCHAR(1024)"`
+}
+
+func TestCreateTable_Build(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		wantSQL string
+	}{
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			wantSQL: "CREATE TABLE IF NOT EXISTS `account`(`id` BIGINT PRIMARY KEY,`name` VARCHAR(255) NOT NULL," +
+				"`status` TINYINT NOT NULL CHECK (`status` IN (1,2)),`role` ENUM('active','blocked') NOT NULL," +
+				"`score` DOUBLE,`nick` VARCHAR(255),`tags` JSON,`avatar` BLOB,`created_at` DATETIME NOT NULL," +
+				"`remark` VARCHAR(1024) NOT NULL);",
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			wantSQL: "CREATE TABLE IF NOT EXISTS `account`(`id` INTEGER PRIMARY KEY,`name` TEXT NOT NULL," +
+				"`status` INTEGER NOT NULL CHECK (`status` IN (1,2)),`role` TEXT NOT NULL CHECK (`role` IN ('active','blocked'))," +
+				"`score` REAL,`nick` TEXT,`tags` TEXT,`avatar` BLOB,`created_at` DATETIME NOT NULL," +
+				"`remark` VARCHAR(1024) NOT NULL);",
+		},
+		{
+			name:    "postgres",
+			dialect: DialectPostgreSQL,
+			wantSQL: `CREATE TABLE IF NOT EXISTS "account"("id" BIGINT PRIMARY KEY,"name" TEXT NOT NULL,` +
+				`"status" SMALLINT NOT NULL CHECK ("status" IN (1,2)),"role" TEXT NOT NULL CHECK ("role" IN ('active','blocked')),` +
+				`"score" DOUBLE PRECISION,"nick" TEXT,"tags" JSONB,"avatar" BYTEA,"created_at" TIMESTAMP NOT NULL,` +
+				`"remark" VARCHAR(1024) NOT NULL);`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			q, err := NewCreateTable[Account](db).IfNotExists().Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			q, err = NewCreateTableOf(db, &Account{}).IfNotExists().Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSQL, q.SQL)
+		})
+	}
+
+	type Unsupported struct {
+		Id   int64
+		Meta map[string]string
+	}
+	_, err := NewCreateTable[Unsupported](memoryDB(t)).Build()
+	assert.Equal(t, errs.NewErrUnsupportedColumnType(reflect.TypeOf(map[string]string{})), err)
+}
+
+func TestEnum_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:enum.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	// 下面的 PRAGMA 只对当前连接生效
+	db.db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	require.NoError(t, NewCreateTable[Account](db).Exec(ctx).Err())
+
+	acc := &Account{Id: 1, Name: "Tom", Status: UserBlocked, Role: UserActive, Tags: []string{"a"},
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, NewInserter[Account](db).Values(acc).Exec(ctx).Err())
+	var status int64
+	var role string
+	require.NoError(t, db.db.QueryRow("SELECT `status`,`role` FROM `account`").Scan(&status, &role))
+	assert.Equal(t, int64(2), status)
+	assert.Equal(t, "active", role)
+	res, err := NewSelector[Account](db).Where(C("Status").EQ(UserBlocked)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, acc, res)
+	// 查询条件里面的枚举也要按照标签转换
+	res, err = NewSelector[Account](db).Where(C("Role").EQ(UserActive), C("Role").In(UserActive, UserBlocked)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, acc, res)
+
+	// 写入未知的值直接失败，不会发到数据库
+	err = NewInserter[Account](db).Values(&Account{Id: 2, Status: UserStatus(7), Role: UserActive}).Exec(ctx).Err()
+	var enumErr *UnknownEnumError
+	require.True(t, errors.As(err, &enumErr))
+
+	// 绕过 ORM 写入的非法值会被 CHECK 拒绝
+	_, err = db.db.Exec("INSERT INTO `account`(`id`,`name`,`status`,`role`,`created_at`,`remark`) VALUES (3,'x',7,'active','2024-01-01','')")
+	assert.Error(t, err)
+
+	// 读到未知的值的时候返回 UnknownEnumError
+	_, err = db.db.Exec("UPDATE `account` SET `role` = 'ghost'")
+	assert.Error(t, err)
+	_, err = db.db.Exec("PRAGMA ignore_check_constraints = ON")
+	require.NoError(t, err)
+	_, err = db.db.Exec("UPDATE `account` SET `role` = 'ghost'")
+	require.NoError(t, err)
+	_, err = NewSelector[Account](db).Get(ctx)
+	require.True(t, errors.As(err, &enumErr))
+	assert.Equal(t, "ghost", enumErr.Value)
+}
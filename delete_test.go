@@ -3,6 +3,7 @@ package orm
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"orm/internal/errs"
 	"testing"
 )
 
@@ -103,3 +104,83 @@ func TestDeleter_Build(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleter_Join(t *testing.T) {
+	o := TableOf(&Order{}).As("o")
+	u := TableOf(&TestModel{}).As("u")
+	join := o.Join(u).On(o.C("UserId").EQ(u.C("Id")))
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		table   TableReference
+		// where 为空的时候使用 u.age > 18
+		where     []Predicate
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "alias",
+			dialect: DialectMySQL,
+			table:   o,
+			where:   []Predicate{o.C("UserId").EQ(1)},
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order` AS `o` WHERE `o`.`user_id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			table:   join,
+			wantQuery: &Query{
+				SQL:  "DELETE `o` FROM `order` AS `o` JOIN `test_model` AS `u` ON `o`.`user_id` = `u`.`id` WHERE `u`.`age` > ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "postgres",
+			dialect: DialectPostgreSQL,
+			table:   join,
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "order" AS "o" USING "test_model" AS "u" WHERE ("o"."user_id" = "u"."id") AND ("u"."age" > ?);`,
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			table:   join,
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `order` AS `o` WHERE `o`.rowid IN (SELECT `o`.rowid FROM `order` AS `o` JOIN `test_model` AS `u` ON `o`.`user_id` = `u`.`id` WHERE `u`.`age` > ?);",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "join target",
+			dialect: DialectMySQL,
+			table:   u.Join(o).On(o.C("UserId").EQ(u.C("Id"))),
+			wantErr: errs.ErrJoinTarget,
+		},
+		{
+			name:    "subquery",
+			dialect: DialectMySQL,
+			table:   SubQuery{},
+			wantErr: errs.NewErrUnSupportedTable(SubQuery{}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			where := tc.where
+			if len(where) == 0 {
+				where = []Predicate{u.C("Age").GT(18)}
+			}
+			q, err := NewDeleter[Order](db).Table(tc.table).Where(where...).Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
@@ -1,13 +1,27 @@
 package orm
 
+import (
+	"context"
+	"orm/internal/errs"
+)
+
 type Deleter[T any] struct {
 	builder
 	where     []Predicate
 	tableName string
+	table     TableReference
 }
 
 func (s *Deleter[T]) Build() (*Query, error) {
-	s.sb.WriteString("DELETE FROM ")
+	stmt, err := s.statement()
+	if err != nil {
+		return nil, err
+	}
+	return s.render(stmt)
+}
+
+func (s *Deleter[T]) statement() (*Statement, error) {
+	s = s.Clone()
 	var (
 		t   T
 		err error
@@ -16,25 +30,52 @@ func (s *Deleter[T]) Build() (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(s.tableName) != 0 {
-		s.sb.WriteString(s.tableName)
-	} else {
-		s.sb.WriteByte('`')
-		s.sb.WriteString(s.Model.TableName)
-		s.sb.WriteByte('`')
+	table, scopes, err := s.scoped("DELETE", s, s.table)
+	if err != nil {
+		return nil, err
 	}
-	if len(s.where) > 0 {
-		s.sb.WriteString(" WHERE ")
-		err = s.buildPredicates(s.where)
+	stmt := s.newStatement("DELETE")
+	stmt.Table, stmt.Where, stmt.Scopes = table, s.where, scopes
+	return stmt, s.rewrite(stmt, s.tableName)
+}
+
+func (s *Deleter[T]) render(stmt *Statement) (*Query, error) {
+	s = s.Clone()
+	if err := s.apply(stmt); err != nil {
+		return nil, err
+	}
+	s.table = stmt.Table
+	where := stmt.predicates()
+	var err error
+	switch tbl := s.table.(type) {
+	case Join:
+		stmt, err := s.newJoinStmt(tbl, where)
 		if err != nil {
 			return nil, err
 		}
+		if err = s.dialect.buildJoinDelete(&s.builder, stmt); err != nil {
+			return nil, err
+		}
+	case nil, Table:
+		if tbl != nil {
+			if err = s.checkTarget(tbl.(Table)); err != nil {
+				return nil, err
+			}
+		}
+		s.sb.WriteString("DELETE FROM ")
+		if len(s.tableName) != 0 {
+			s.sb.WriteString(s.tableName)
+		} else if err = s.buildTable(s.table); err != nil {
+			return nil, err
+		}
+		if err = s.buildWhere(where); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errs.NewErrUnSupportedTable(tbl)
 	}
 	s.sb.WriteByte(';')
-	return &Query{
-		SQL:  s.sb.String(),
-		Args: s.args,
-	}, nil
+	return s.build()
 }
 
 func (s *Deleter[T]) Where(ps ...Predicate) *Deleter[T] {
@@ -42,14 +83,40 @@ func (s *Deleter[T]) Where(ps ...Predicate) *Deleter[T] {
 	return s
 }
 
+// Unscoped 不使用 Scope 和多租户隔离
+func (s *Deleter[T]) Unscoped() *Deleter[T] {
+	s.unscoped = true
+	return s
+}
+
 func (s *Deleter[T]) From(tableName string) *Deleter[T] {
 	s.tableName = tableName
 	return s
 }
 
+// Table 指定要删除的表，可以是 Join，这时候只删除 JOIN 最左边的表里面的数据，它必须是 T 对应的表
+func (s *Deleter[T]) Table(table TableReference) *Deleter[T] {
+	s.table = table
+	return s
+}
+
 func NewDeleter[T any](sess Session) *Deleter[T] {
 	core := sess.getCore()
 	return &Deleter[T]{
 		builder: builder{sess: sess, core: core},
 	}
 }
+
+func (s *Deleter[T]) Exec(ctx context.Context) Result {
+	res := exec[T](ctx, s.sess, s.core, &QueryContext{
+		Type:    "DELETE",
+		Builder: s,
+		Model:   s.Model,
+	})
+	if res.Result != nil {
+		return res.Result.(Result)
+	}
+	return Result{
+		err: res.Err,
+	}
+}
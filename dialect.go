@@ -2,16 +2,126 @@ package orm
 
 import (
 	"orm/internal/errs"
+	"strconv"
+	"strings"
 )
 
 type Dialect interface {
 	quoter() byte
+	// bindVar 返回第 idx 个参数的占位符，idx 从 1 开始
+	bindVar(idx int) string
 	buildUpsert(build *builder, upsert *Upsert) error
+	// insertIgnore 返回跳过冲突行的 INSERT 开头和 VALUES 后面的部分
+	insertIgnore() (verb string, suffix string)
+	buildJoinUpdate(b *builder, stmt joinStmt) error
+	buildJoinDelete(b *builder, stmt joinStmt) error
+	// buildJSON 构造 JSONExpr、JSONContains 和 JSONSet 的右边
+	buildJSON(b *builder, expr any) error
+	// columnType 把 ddl.go 里面归类之后的类型转换成建表使用的类型
+	columnType(kind string) string
+	// enumType 返回枚举专用的列类型，不支持的时候返回 false，这时候使用 CHECK
+	enumType(vals []any) (string, bool)
+	// explainPrefix 加在查询前面得到执行计划
+	explainPrefix() string
+	// explainRow 把 EXPLAIN 的一行转换成 ExplainRow
+	explainRow(raw map[string]any) ExplainRow
+	// buildIndexHint 在表名后面写上索引提示，不支持的时候什么都不写
+	buildIndexHint(b *builder, h indexHint)
+	// maxPlaceholders 返回一条语句最多可以有的参数个数，ExecBatch 按照它来切分
+	maxPlaceholders() int
 }
 
+var (
+	DialectMySQL      Dialect = mysqlDialect{}
+	DialectSQLite     Dialect = sqliteDialect{}
+	DialectPostgreSQL Dialect = postgreDialect{}
+)
+
 type standardSQL struct {
 }
 
+func (s standardSQL) bindVar(idx int) string {
+	return "?"
+}
+
+// maxPlaceholders MySQL 和 PostgreSQL 都是 65535
+func (s standardSQL) maxPlaceholders() int {
+	return 65535
+}
+
+func (s standardSQL) insertIgnore() (string, string) {
+	return "INSERT INTO ", " ON CONFLICT DO NOTHING"
+}
+
+// buildJoinUpdate 构造 UPDATE a SET ... FROM b WHERE ...，PostgreSQL 和 SQLite 都是这种写法
+func (s standardSQL) buildJoinUpdate(b *builder, stmt joinStmt) error {
+	first, rest, err := splitJoin(stmt.join)
+	if err != nil {
+		return err
+	}
+	b.sb.WriteString("UPDATE ")
+	if err = b.buildTable(stmt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" SET ")
+	if err = stmt.set(false); err != nil {
+		return err
+	}
+	b.sb.WriteString(" FROM ")
+	if err = b.buildTable(rest); err != nil {
+		return err
+	}
+	return b.buildWhere(append(first.on[:len(first.on):len(first.on)], stmt.where...))
+}
+
+// buildJoinDelete 构造 DELETE FROM a USING b WHERE ...
+func (s standardSQL) buildJoinDelete(b *builder, stmt joinStmt) error {
+	first, rest, err := splitJoin(stmt.join)
+	if err != nil {
+		return err
+	}
+	b.sb.WriteString("DELETE FROM ")
+	if err = b.buildTable(stmt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" USING ")
+	if err = b.buildTable(rest); err != nil {
+		return err
+	}
+	return b.buildWhere(append(first.on[:len(first.on):len(first.on)], stmt.where...))
+}
+
+var standardTypes = map[string]string{
+	colBool:    "BOOLEAN",
+	colInt8:    "SMALLINT",
+	colInt16:   "SMALLINT",
+	colInt32:   "INTEGER",
+	colInt64:   "BIGINT",
+	colUint8:   "SMALLINT",
+	colUint16:  "INTEGER",
+	colUint32:  "BIGINT",
+	colUint64:  "NUMERIC(20)",
+	colFloat32: "REAL",
+	colFloat64: "DOUBLE PRECISION",
+	colString:  "TEXT",
+	colText:    "TEXT",
+	colBytes:   "BYTEA",
+	colTime:    "TIMESTAMP",
+	colJSON:    "JSONB",
+}
+
+func (s standardSQL) columnType(kind string) string {
+	return standardTypes[kind]
+}
+
+func (s standardSQL) enumType(vals []any) (string, bool) {
+	return "", false
+}
+
+func (s standardSQL) buildJSON(b *builder, expr any) error {
+	return errs.NewErrUnsupportedJSON(expr)
+}
+
 func (s standardSQL) quoter() byte {
 	//TODO implement me
 	panic("implement me")
@@ -40,8 +150,14 @@ func (s mysqlDialect) buildUpsert(build *builder, upsert *Upsert) error {
 			}
 			build.sb.WriteString(build.Model.Fields[a.col].ColName)
 			build.sb.WriteByte('`')
-			build.sb.WriteString("=?")
-			build.addArg(a.val)
+			build.sb.WriteByte('=')
+			val, err := assignValue(build.Model.Fields[a.col], a.val)
+			if err != nil {
+				return err
+			}
+			if err = build.buildExpression(val); err != nil {
+				return err
+			}
 		case Column:
 			if _, ok := build.Model.Fields[a.name]; !ok {
 				return errs.NewErrUnKnownField(a.name)
@@ -57,6 +173,100 @@ func (s mysqlDialect) buildUpsert(build *builder, upsert *Upsert) error {
 	return nil
 }
 
+func (s mysqlDialect) insertIgnore() (string, string) {
+	return "INSERT IGNORE INTO ", ""
+}
+
+// buildJoinUpdate 构造 UPDATE a JOIN b ON ... SET a.x=... WHERE ...
+func (s mysqlDialect) buildJoinUpdate(b *builder, stmt joinStmt) error {
+	b.sb.WriteString("UPDATE ")
+	if err := b.buildJoin(stmt.join); err != nil {
+		return err
+	}
+	b.sb.WriteString(" SET ")
+	if err := stmt.set(true); err != nil {
+		return err
+	}
+	return b.buildWhere(stmt.where)
+}
+
+// buildJoinDelete 构造 DELETE a FROM a JOIN b ON ... WHERE ...
+func (s mysqlDialect) buildJoinDelete(b *builder, stmt joinStmt) error {
+	b.sb.WriteString("DELETE ")
+	b.buildTargetName(stmt.target)
+	b.sb.WriteString(" FROM ")
+	if err := b.buildJoin(stmt.join); err != nil {
+		return err
+	}
+	return b.buildWhere(stmt.where)
+}
+
+var mysqlTypes = map[string]string{
+	colBool:    "TINYINT(1)",
+	colInt8:    "TINYINT",
+	colInt16:   "SMALLINT",
+	colInt32:   "INT",
+	colInt64:   "BIGINT",
+	colUint8:   "TINYINT UNSIGNED",
+	colUint16:  "SMALLINT UNSIGNED",
+	colUint32:  "INT UNSIGNED",
+	colUint64:  "BIGINT UNSIGNED",
+	colFloat32: "FLOAT",
+	colFloat64: "DOUBLE",
+	colString:  "VARCHAR(255)",
+	colText:    "TEXT",
+	colBytes:   "BLOB",
+	colTime:    "DATETIME",
+	colJSON:    "JSON",
+}
+
+func (s mysqlDialect) columnType(kind string) string {
+	return mysqlTypes[kind]
+}
+
+// enumType 按照文本存储的枚举使用 ENUM(...)
+func (s mysqlDialect) enumType(vals []any) (string, bool) {
+	if _, ok := vals[0].(string); !ok {
+		return "", false
+	}
+	return "ENUM(" + enumLiterals(vals) + ")", true
+}
+
+// buildJSON 路径已经校验过了，所以 ->> 后面可以直接写路径
+func (s mysqlDialect) buildJSON(b *builder, expr any) error {
+	switch e := expr.(type) {
+	case JSONExpr:
+		if _, err := jsonSegments(e.path); err != nil {
+			return err
+		}
+		if e.fn == jsonArrayLength {
+			return b.buildJSONCall("JSON_LENGTH(", e.col, e.path)
+		}
+		if err := b.buildColumn(e.col); err != nil {
+			return err
+		}
+		b.sb.WriteString("->>'")
+		b.sb.WriteString(e.path)
+		b.sb.WriteByte('\'')
+	case jsonContains:
+		arg, err := jsonArg(e.val)
+		if err != nil {
+			return err
+		}
+		b.sb.WriteString("JSON_CONTAINS(")
+		if err = b.buildColumn(e.col); err != nil {
+			return err
+		}
+		b.sb.WriteString(",?)")
+		b.addArg(arg)
+	case JSONAssignment:
+		return b.buildJSONSet("JSON_SET(", e, "CAST(? AS JSON)")
+	default:
+		return errs.NewErrUnsupportedJSON(expr)
+	}
+	return nil
+}
+
 func (s mysqlDialect) quoter() byte {
 	return '`'
 }
@@ -65,6 +275,11 @@ type sqliteDialect struct {
 	standardSQL
 }
 
+// maxPlaceholders 是 SQLite 3.32.0 之后 SQLITE_MAX_VARIABLE_NUMBER 的默认值，之前的版本是 999
+func (s sqliteDialect) maxPlaceholders() int {
+	return 32766
+}
+
 func (s sqliteDialect) buildUpsert(build *builder, upsert *Upsert) error {
 	build.sb.WriteString(" ON CONFLICT(")
 	for i, col := range upsert.conflictColumns {
@@ -89,8 +304,14 @@ func (s sqliteDialect) buildUpsert(build *builder, upsert *Upsert) error {
 			}
 			build.sb.WriteString(build.Model.Fields[a.col].ColName)
 			build.sb.WriteByte('`')
-			build.sb.WriteString("=?")
-			build.addArg(a.val)
+			build.sb.WriteByte('=')
+			val, err := assignValue(build.Model.Fields[a.col], a.val)
+			if err != nil {
+				return err
+			}
+			if err = build.buildExpression(val); err != nil {
+				return err
+			}
 		case Column:
 			if _, ok := build.Model.Fields[a.name]; !ok {
 				return errs.NewErrUnKnownField(a.name)
@@ -105,6 +326,73 @@ func (s sqliteDialect) buildUpsert(build *builder, upsert *Upsert) error {
 	return nil
 }
 
+// buildJoinDelete SQLite 的 DELETE 不支持 JOIN，通过 rowid 子查询来删除
+func (s sqliteDialect) buildJoinDelete(b *builder, stmt joinStmt) error {
+	b.sb.WriteString("DELETE FROM ")
+	if err := b.buildTable(stmt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" WHERE ")
+	b.buildTargetName(stmt.target)
+	b.sb.WriteString(".rowid IN (SELECT ")
+	b.buildTargetName(stmt.target)
+	b.sb.WriteString(".rowid FROM ")
+	if err := b.buildJoin(stmt.join); err != nil {
+		return err
+	}
+	if err := b.buildWhere(stmt.where); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// columnType SQLite 只有几种存储类型，时间要声明成 DATETIME 驱动才会转换成 time.Time
+func (s sqliteDialect) columnType(kind string) string {
+	switch kind {
+	case colFloat32, colFloat64:
+		return "REAL"
+	case colTime:
+		return "DATETIME"
+	case colString, colText, colJSON:
+		return "TEXT"
+	case colBytes:
+		return "BLOB"
+	default:
+		return "INTEGER"
+	}
+}
+
+// buildJSON 使用 JSON1 扩展的函数
+func (s sqliteDialect) buildJSON(b *builder, expr any) error {
+	switch e := expr.(type) {
+	case JSONExpr:
+		if _, err := jsonSegments(e.path); err != nil {
+			return err
+		}
+		fn := "json_extract("
+		if e.fn == jsonArrayLength {
+			fn = "json_array_length("
+		}
+		return b.buildJSONCall(fn, e.col, e.path)
+	case jsonContains:
+		if !isJSONScalar(e.val) {
+			return errs.NewErrUnsupportedJSON(e.val)
+		}
+		b.sb.WriteString("EXISTS (SELECT 1 FROM json_each(")
+		if err := b.buildColumn(e.col); err != nil {
+			return err
+		}
+		b.sb.WriteString(") WHERE value=?)")
+		b.addArg(e.val)
+	case JSONAssignment:
+		return b.buildJSONSet("json_set(", e, "json(?)")
+	default:
+		return errs.NewErrUnsupportedJSON(expr)
+	}
+	return nil
+}
+
 func (s sqliteDialect) quoter() byte {
 	return '`'
 }
@@ -112,3 +400,82 @@ func (s sqliteDialect) quoter() byte {
 type postgreDialect struct {
 	standardSQL
 }
+
+func (s postgreDialect) quoter() byte {
+	return '"'
+}
+
+// buildJSON 假设列是 jsonb，路径转换成 {a,b} 这种文本数组
+func (s postgreDialect) buildJSON(b *builder, expr any) error {
+	switch e := expr.(type) {
+	case JSONExpr:
+		segs, err := jsonSegments(e.path)
+		if err != nil {
+			return err
+		}
+		if e.fn == jsonArrayLength {
+			b.sb.WriteString("jsonb_array_length(")
+			if err = b.buildColumn(e.col); err != nil {
+				return err
+			}
+			if len(segs) > 0 {
+				b.sb.WriteString("#>?::text[]")
+				b.addArg(pgPath(segs))
+			}
+			b.sb.WriteByte(')')
+			return nil
+		}
+		if err = b.buildColumn(e.col); err != nil {
+			return err
+		}
+		// 只有一层对象的键的时候用 ->>，数组下标和多层路径用 #>>
+		if len(segs) == 1 && strings.HasPrefix(e.path, "$.") {
+			b.sb.WriteString("->>?")
+			b.addArg(segs[0])
+			return nil
+		}
+		b.sb.WriteString("#>>?::text[]")
+		b.addArg(pgPath(segs))
+	case jsonContains:
+		arg, err := jsonArg(e.val)
+		if err != nil {
+			return err
+		}
+		if err = b.buildColumn(e.col); err != nil {
+			return err
+		}
+		b.sb.WriteString(" @> ?::jsonb")
+		b.addArg(arg)
+	case JSONAssignment:
+		// jsonb_set 一次只能修改一个路径，所以一层套一层
+		for range e.paths {
+			b.sb.WriteString("jsonb_set(")
+		}
+		if err := b.buildColumn(e.col); err != nil {
+			return err
+		}
+		for i, path := range e.paths {
+			segs, err := jsonSegments(path)
+			if err != nil {
+				return err
+			}
+			arg, err := jsonArg(e.vals[i])
+			if err != nil {
+				return err
+			}
+			b.sb.WriteString(",?::text[],?::jsonb)")
+			b.addArg(pgPath(segs), arg)
+		}
+	default:
+		return errs.NewErrUnsupportedJSON(expr)
+	}
+	return nil
+}
+
+func pgPath(segs []string) string {
+	return "{" + strings.Join(segs, ",") + "}"
+}
+
+func (s postgreDialect) bindVar(idx int) string {
+	return "$" + strconv.Itoa(idx)
+}
@@ -3,3 +3,13 @@ package orm
 import "orm/internal/errs"
 
 var ErrNoRows = errs.ErrNoRows
+
+// NewErrUnknownField 给生成的代码使用，name 是模型里面没有的字段
+func NewErrUnknownField(name string) error {
+	return errs.NewErrUnKnownField(name)
+}
+
+// NewErrUnknownColumn 给生成的代码使用，col 是模型里面没有的列
+func NewErrUnknownColumn(col string) error {
+	return errs.NewErrUnKnownColumn(col)
+}
@@ -0,0 +1,168 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"orm/internal/errs"
+	"strconv"
+	"strings"
+)
+
+// ExplainRow 是执行计划里面的一行，不同数据库的列不一样，原始的列都在 Raw 里面
+type ExplainRow struct {
+	// Table MySQL 的 table 列
+	Table string
+	// Access MySQL 的 type 列，比如 ALL、ref、const
+	Access string
+	// Key MySQL 实际使用的索引
+	Key string
+	// Rows MySQL 估算的扫描行数
+	Rows int64
+	// Detail MySQL 的 Extra，SQLite 的 detail，PostgreSQL 的 QUERY PLAN
+	Detail string
+	// FullScan 是否是全表扫描
+	FullScan bool
+	// Filesort 是否需要额外排序
+	Filesort bool
+	Raw      map[string]any
+}
+
+// Plan 是 EXPLAIN 的结果
+type Plan struct {
+	SQL  string
+	Rows []ExplainRow
+}
+
+// FullScan 是否有任何一步是全表扫描
+func (p *Plan) FullScan() bool {
+	for _, r := range p.Rows {
+		if r.FullScan {
+			return true
+		}
+	}
+	return false
+}
+
+// Filesort 是否有任何一步需要额外排序
+func (p *Plan) Filesort() bool {
+	for _, r := range p.Rows {
+		if r.Filesort {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain 返回查询的执行计划，SQLite 使用的是 EXPLAIN QUERY PLAN
+func (s *Selector[T]) Explain(ctx context.Context) (*Plan, error) {
+	q, err := bindContext(ctx, s).Build()
+	if err != nil {
+		return nil, err
+	}
+	return explain(ctx, s.sess, s.core, q)
+}
+
+// Explain 返回 q 的执行计划，q 使用 ? 作为占位符，一般是 QueryContext.Query 的结果
+func (db *DB) Explain(ctx context.Context, q *Query) (*Plan, error) {
+	return explain(ctx, db, db.core, q)
+}
+
+// Explain 返回正在执行的语句的执行计划，给中间件使用
+func (qc *QueryContext) Explain(ctx context.Context) (*Plan, error) {
+	if qc.sess == nil {
+		return nil, errs.ErrExplainNoSession
+	}
+	q, err := qc.Query()
+	if err != nil {
+		return nil, err
+	}
+	// 已经路由过了，压测流量的 sess 是影子库，直接在上面执行
+	return explainRouted(ctx, qc.sess, qc.core, q)
+}
+
+// explain 不经过中间件，避免采样的中间件再去解释 EXPLAIN 本身
+func explain(ctx context.Context, sess Session, c core, q *Query) (*Plan, error) {
+	sess, c, err := c.route(ctx, sess, false)
+	if err != nil {
+		return nil, err
+	}
+	return explainRouted(ctx, sess, c, q)
+}
+
+func explainRouted(ctx context.Context, sess Session, c core, q *Query) (*Plan, error) {
+	sql := c.dialect.explainPrefix() + q.SQL
+	rows, err := c.queryContext(ctx, sess, &Query{SQL: sql, Args: q.Args})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := &Plan{SQL: q.SQL}
+	for rows.Next() {
+		raw, err := scanMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		res.Rows = append(res.Rows, c.dialect.explainRow(raw))
+	}
+	return res, rows.Err()
+}
+
+func (s standardSQL) explainPrefix() string {
+	return "EXPLAIN "
+}
+
+// explainRow PostgreSQL 每一行是 QUERY PLAN 的一行文本
+func (s standardSQL) explainRow(raw map[string]any) ExplainRow {
+	detail := explainString(raw["QUERY PLAN"])
+	return ExplainRow{
+		Detail:   detail,
+		FullScan: strings.Contains(detail, "Seq Scan"),
+		Filesort: strings.Contains(detail, "Sort Key:"),
+		Raw:      raw,
+	}
+}
+
+func (s mysqlDialect) explainRow(raw map[string]any) ExplainRow {
+	access := explainString(raw["type"])
+	extra := explainString(raw["Extra"])
+	rows, _ := strconv.ParseInt(explainString(raw["rows"]), 10, 64)
+	return ExplainRow{
+		Table:    explainString(raw["table"]),
+		Access:   access,
+		Key:      explainString(raw["key"]),
+		Rows:     rows,
+		Detail:   extra,
+		FullScan: access == "ALL",
+		Filesort: strings.Contains(extra, "Using filesort"),
+		Raw:      raw,
+	}
+}
+
+func (s sqliteDialect) explainPrefix() string {
+	return "EXPLAIN QUERY PLAN "
+}
+
+// explainRow SQLite 的 detail 是 SCAN t 或者 SEARCH t USING INDEX ...，
+// 老版本是 SCAN TABLE t，带 USING 的 SCAN 是按照索引遍历，不算全表扫描
+func (s sqliteDialect) explainRow(raw map[string]any) ExplainRow {
+	detail := explainString(raw["detail"])
+	return ExplainRow{
+		Detail:   detail,
+		FullScan: strings.HasPrefix(detail, "SCAN ") && !strings.Contains(detail, " USING "),
+		Filesort: strings.Contains(detail, "USE TEMP B-TREE FOR ORDER BY"),
+		Raw:      raw,
+	}
+}
+
+func explainString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
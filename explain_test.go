@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Explain_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:explain.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(TestModel{}.CreateSQL())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name         string
+		s            *Selector[TestModel]
+		wantFullScan bool
+		wantFilesort bool
+	}{
+		{
+			name: "primary key",
+			s:    NewSelector[TestModel](db).Where(C("Id").EQ(1)),
+		},
+		{
+			name:         "full scan",
+			s:            NewSelector[TestModel](db).Where(C("Age").GT(18)),
+			wantFullScan: true,
+		},
+		{
+			name:         "filesort",
+			s:            NewSelector[TestModel](db).Where(C("Id").GT(1)).OrderBy(Asc("FirstName")),
+			wantFilesort: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := tc.s.Explain(ctx)
+			require.NoError(t, err)
+			require.NotEmpty(t, plan.Rows)
+			assert.Equal(t, tc.wantFullScan, plan.FullScan(), plan.Rows)
+			assert.Equal(t, tc.wantFilesort, plan.Filesort(), plan.Rows)
+		})
+	}
+}
+
+func TestSelector_Explain_MySQL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"id", "select_type", "table", "type", "key", "rows", "Extra"})
+	rows.AddRow(1, "SIMPLE", "test_model", "ALL", nil, 1000, "Using where; Using filesort")
+	mock.ExpectQuery("EXPLAIN SELECT \\* FROM `test_model` WHERE `age` > \\? ORDER BY `first_name` ASC;").
+		WithArgs(18).WillReturnRows(rows)
+	plan, err := NewSelector[TestModel](db).Where(C("Age").GT(18)).OrderBy(Asc("FirstName")).Explain(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plan.Rows, 1)
+	row := plan.Rows[0]
+	assert.Equal(t, "test_model", row.Table)
+	assert.Equal(t, "ALL", row.Access)
+	assert.Equal(t, int64(1000), row.Rows)
+	assert.True(t, plan.FullScan())
+	assert.True(t, plan.Filesort())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,24 +1,48 @@
 package main
 
-import "go/ast"
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
 
 type SingleFileEntryVisitor struct {
 	file *FileVisitor
 }
 
-func (s *SingleFileEntryVisitor) Get() *File {
+func (s *SingleFileEntryVisitor) Get() (*File, error) {
 	types := make([]Type, 0, len(s.file.Types))
+	used := make(map[string]struct{})
 	for _, t := range s.file.Types {
+		if t.err != nil {
+			return nil, t.err
+		}
 		types = append(types, Type{
 			Name:   t.Name,
 			Fields: t.Fields,
 		})
+		for _, fd := range t.Fields {
+			for _, pkg := range fd.pkgs {
+				used[pkg] = struct{}{}
+			}
+		}
+	}
+	// 只保留字段类型用到的导入，否则生成的文件会有没用到的导入
+	imports := make([]string, 0, len(s.file.Imports))
+	for _, imp := range s.file.Imports {
+		if _, ok := used[imp.name]; ok {
+			imports = append(imports, imp.spec)
+		}
 	}
 	return &File{
 		Package: s.file.Package,
-		Imports: s.file.Imports,
+		Imports: imports,
 		Types:   types,
-	}
+	}, nil
 }
 
 func (s *SingleFileEntryVisitor) Visit(node ast.Node) (w ast.Visitor) {
@@ -34,19 +58,34 @@ func (s *SingleFileEntryVisitor) Visit(node ast.Node) (w ast.Visitor) {
 
 type FileVisitor struct {
 	Package string
-	Imports []string
+	Imports []importSpec
 	Types   []*TypeVisitor
 }
 
+type importSpec struct {
+	// name 是代码里面引用这个包用的名字
+	name string
+	spec string
+}
+
 func (f *FileVisitor) Visit(node ast.Node) (w ast.Visitor) {
 	switch n := node.(type) {
 	case *ast.ImportSpec:
-		path := n.Path.Value
+		path, _ := strconv.Unquote(n.Path.Value)
+		imp := importSpec{
+			name: path[strings.LastIndexByte(path, '/')+1:],
+			spec: n.Path.Value,
+		}
 		if n.Name != nil && n.Name.String() != "" {
-			path = n.Name.String() + " " + path
+			imp.name = n.Name.String()
+			imp.spec = n.Name.String() + " " + n.Path.Value
 		}
-		f.Imports = append(f.Imports, path)
+		f.Imports = append(f.Imports, imp)
 	case *ast.TypeSpec:
+		// 只处理结构体，泛型结构体没有办法注册
+		if _, ok := n.Type.(*ast.StructType); !ok || n.TypeParams != nil {
+			return nil
+		}
 		v := &TypeVisitor{
 			Name: n.Name.String(),
 		}
@@ -65,6 +104,7 @@ type File struct {
 type TypeVisitor struct {
 	Name   string
 	Fields []Field
+	err    error
 }
 
 func (t *TypeVisitor) Visit(node ast.Node) (w ast.Visitor) {
@@ -72,29 +112,35 @@ func (t *TypeVisitor) Visit(node ast.Node) (w ast.Visitor) {
 	if !ok {
 		return t
 	}
-	var typ string
-	switch nt := n.Type.(type) {
-	case *ast.Ident:
-		typ = nt.String()
-	case *ast.StarExpr:
-		switch xt := nt.X.(type) {
-		case *ast.Ident:
-			typ = "*" + xt.String()
-		case *ast.SelectorExpr:
-			typ = "*" + xt.X.(*ast.Ident).String() + "." + xt.Sel.String()
-		}
-	case *ast.ArrayType:
-		typ = "[]byte"
-	default:
-		panic("不支持的类型")
+	typ := types.ExprString(n.Type)
+	pkgs := selectorPackages(n.Type)
+	tags, err := parseTag(n.Tag)
+	if err != nil {
+		t.err = err
+		return nil
 	}
+	names := make([]string, 0, len(n.Names))
 	for _, name := range n.Names {
+		names = append(names, name.String())
+	}
+	if len(names) == 0 {
+		// 组合的字段，和 reflect 一样用类型名作为字段名
+		names = append(names, embeddedName(n.Type))
+	}
+	for _, name := range names {
+		col := tags["column"]
+		if col == "" {
+			col = underscoreName(name)
+		}
 		t.Fields = append(t.Fields, Field{
-			Name: name.String(),
-			Type: typ,
+			Name:   name,
+			Type:   typ,
+			Column: col,
+			pkgs:   pkgs,
 		})
 	}
-	return t
+	// 字段的类型里面的 Field，比如函数的参数，不是结构体的字段
+	return nil
 }
 
 type Type struct {
@@ -105,4 +151,74 @@ type Type struct {
 type Field struct {
 	Name string
 	Type string
+	// Column 是列名，和 model 包的规则一样，优先使用 orm 标签里面的 column
+	Column string
+	// pkgs 是字段类型引用到的包
+	pkgs []string
+}
+
+// selectorPackages 找出类型表达式里面引用到的包，比如 map[string]*sql.NullString 里面的 sql
+func selectorPackages(expr ast.Expr) []string {
+	var pkgs []string
+	ast.Inspect(expr, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok {
+			pkgs = append(pkgs, id.Name)
+		}
+		return false
+	})
+	return pkgs
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	default:
+		return types.ExprString(expr)
+	}
+}
+
+// parseTag 和 model 包解析 orm 标签的规则保持一致
+func parseTag(tag *ast.BasicLit) (map[string]string, error) {
+	res := make(map[string]string)
+	if tag == nil {
+		return res, nil
+	}
+	val, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return nil, err
+	}
+	ormTag := reflect.StructTag(val).Get("orm")
+	if ormTag == "" {
+		return res, nil
+	}
+	for _, pair := range strings.Split(ormTag, ",") {
+		kv := strings.Split(pair, "=")
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("orm: 错误的标签设置: %s", pair)
+		}
+		res[kv[0]] = kv[1]
+	}
+	return res, nil
+}
+
+func underscoreName(name string) string {
+	var buf []byte
+	for i, v := range name {
+		if unicode.IsUpper(v) {
+			if i != 0 {
+				buf = append(buf, '_')
+			}
+			buf = append(buf, byte(unicode.ToLower(v)))
+		} else {
+			buf = append(buf, byte(v))
+		}
+	}
+	return string(buf)
 }
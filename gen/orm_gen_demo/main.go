@@ -1,112 +1,225 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	_ "embed"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
 	"io"
-	"net/http"
-	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"text/template"
-	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 //go:embed tpl.gohtml
 var genOrm string
 
+//go:embed valuer.gohtml
+var genValuer string
+
+//go:embed schema.gohtml
+var genSchema string
+
+var tplFuncs = template.FuncMap{
+	"lowerFirst": func(s string) string {
+		return strings.ToLower(s[:1]) + s[1:]
+	},
+}
+
+// gen 给 srcFile 里面的每个结构体生成带类型的列
 func gen(w io.Writer, srcFile string) error {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, srcFile, nil, parser.ParseComments)
+	return execute(w, srcFile, "gen-orm", genOrm)
+}
+
+// genValuerFile 给 srcFile 里面的每个结构体生成不用反射的 Value，
+// 生成的代码会在 init 里面注册，orm 读写这些结构体的时候自动使用
+func genValuerFile(w io.Writer, srcFile string) error {
+	return execute(w, srcFile, "gen-valuer", genValuer)
+}
+
+func execute(w io.Writer, srcFile string, name string, text string) error {
+	file, err := parseFile(srcFile)
 	if err != nil {
 		return err
 	}
-	s := &SingleFileEntryVisitor{}
-	ast.Walk(s, f)
-	file := s.Get()
-	tpl := template.New("gen-orm")
-	tpl, err = tpl.Parse(genOrm)
+	return render(w, name, text, file)
+}
+
+// genModels 读取 tables 的表结构生成模型，tables 为空的时候生成所有的表
+func genModels(ctx context.Context, w io.Writer, r SchemaReader, pkg string, tables ...string) error {
+	var err error
+	if len(tables) == 0 {
+		tables, err = r.Tables(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	tbls := make([]Table, 0, len(tables))
+	for _, name := range tables {
+		tbl, err := r.Table(ctx, name)
+		if err != nil {
+			return err
+		}
+		tbls = append(tbls, tbl)
+	}
+	return render(w, "gen-schema", genSchema, schemaFile(pkg, tbls))
+}
+
+func render(w io.Writer, name string, text string, data any) error {
+	tpl, err := template.New(name).Funcs(tplFuncs).Parse(text)
 	if err != nil {
 		return err
 	}
-	return tpl.Execute(w, Data{
-		File: file,
-		Ops:  []string{"LT", "GT", "EQ"},
-	})
+	buf := &bytes.Buffer{}
+	if err = tpl.Execute(buf, data); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
 }
 
-type Data struct {
-	*File
-	Ops []string
+func parseFile(srcFile string) (*File, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, srcFile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	s := &SingleFileEntryVisitor{}
+	ast.Walk(s, f)
+	return s.Get()
 }
 
+// 用法：
+//
+//	//go:generate go run orm/gen/orm_gen_demo $GOFILE
+//
+// 参数可以是文件也可以是目录，是目录的时候处理目录下面所有的 Go 文件。
+// 对于 user.go，生成带类型的列 user.gen.go 和不用反射的 Value user.valuer.gen.go。
+//
+// 从已有的表生成模型：
+//
+//	orm_gen_demo schema -driver mysql -dsn "root:root@tcp(localhost:3306)/test" -pkg model -o model.go [table...]
 func main() {
-	//src := os.Args[1]
-	//dstDir := filepath.Dir(src)
-	//fileName := filepath.Base(src)
-	//idx := strings.LastIndexByte(fileName, '.')
-	//dst := filepath.Join(dstDir, fileName[:idx]+".gen.go")
-	//f, err := os.Create(dst)
-	//if err != nil {
-	//	fmt.Println(err)
-	//	return
-	//}
-	//err = gen(f, src)
-	//if err != nil {
-	//	fmt.Println(err)
-	//	return
-	//}
-	//fmt.Println("生成成功")
-	aa, er := updateRespTime("http://localhost:9090", "geekbang_web_http_response{method=\"GET\",quantile=\"0.5\"}")
-	fmt.Println(aa.Data.Result[0].Value[1])
-	fmt.Println(er)
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		if err := schema(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	noValuer := flag.Bool("no-valuer", false, "不生成 Value")
+	flag.Parse()
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for _, path := range paths {
+		if err := run(path, !*noValuer); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 }
 
-func updateRespTime(endpoint, query string) (*QueryInfo, error) {
-	info := &QueryInfo{}
-	ustr := endpoint + "/api/v1/query?query=" + query
-	u, err := url.Parse(ustr)
-	if err != nil {
-		return info, err
+func schema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	driver := fs.String("driver", "mysql", "数据库驱动，支持 mysql 和 sqlite3")
+	dsn := fs.String("dsn", "", "数据库连接")
+	pkg := fs.String("pkg", "model", "生成的代码的包名")
+	out := fs.String("o", "", "输出的文件，默认输出到标准输出")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
-	u.RawQuery = u.Query().Encode()
-	//http://your_prometheus.com/api/v1/query?query=xxx
-	err = GetPromResult(u.String(), &info)
+	db, err := sql.Open(*driver, *dsn)
 	if err != nil {
-		return info, err
+		return err
 	}
-	fmt.Println(info.Data.Result[0].Metric)
-	return info, nil
-}
-
-func GetPromResult(url string, result interface{}) error {
-	httpClient := &http.Client{Timeout: 10 * time.Second}
-	r, err := httpClient.Get(url)
+	defer db.Close()
+	r, err := NewSchemaReader(*driver, db)
 	if err != nil {
 		return err
 	}
+	buf := &bytes.Buffer{}
+	if err = genModels(context.Background(), buf, r, *pkg, fs.Args()...); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*out, buf.Bytes(), 0644)
+}
 
-	defer r.Body.Close()
-	err = json.NewDecoder(r.Body).Decode(result)
+func run(path string, valuer bool) error {
+	srcs, err := sourceFiles(path)
 	if err != nil {
 		return err
 	}
+	for _, src := range srcs {
+		file, err := parseFile(src)
+		if err != nil {
+			return err
+		}
+		if len(file.Types) == 0 {
+			continue
+		}
+		prefix := strings.TrimSuffix(src, ".go")
+		if err = writeFile(prefix+".gen.go", src, gen); err != nil {
+			return err
+		}
+		if !valuer {
+			continue
+		}
+		if err = writeFile(prefix+".valuer.gen.go", src, genValuerFile); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-type ResultType struct {
-	Metric map[string]string `json:"metric"`
-	Value  []interface{}     `json:"value"`
-}
-
-type QueryData struct {
-	ResultType string       `json:"resultType"`
-	Result     []ResultType `json:"result"`
+// sourceFiles 返回需要处理的文件，跳过测试文件和生成的文件
+func sourceFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") ||
+			strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, ".gen.go") {
+			continue
+		}
+		res = append(res, filepath.Join(path, name))
+	}
+	return res, nil
 }
 
-type QueryInfo struct {
-	Status string    `json:"status"`
-	Data   QueryData `json:"data"`
+func writeFile(dst, src string, fn func(w io.Writer, srcFile string) error) error {
+	buf := &bytes.Buffer{}
+	if err := fn(buf, src); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, buf.Bytes(), 0644)
 }
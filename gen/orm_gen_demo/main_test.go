@@ -4,93 +4,72 @@ import (
 	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
 func Test_gen(t *testing.T) {
-	buffer := &bytes.Buffer{}
-	gen(buffer, "testdata/user.go")
-	assert.Equal(t, `package testdata
-
-import (
-    "orm"
-    "database/sql"
-)
-
-const (
-   UserName = "Name"
-   UserAge = "Age"
-   UserNickName = "NickName"
-   UserPicture = "Picture"
-   UserDetailAddress = "Address"
-)
-
-func UserNameLT(val string) orm.Predicate {
-    return orm.C("Name").LT(val)
-}
-
-func UserNameGT(val string) orm.Predicate {
-    return orm.C("Name").GT(val)
-}
-
-func UserNameEQ(val string) orm.Predicate {
-    return orm.C("Name").EQ(val)
-}
-
-func UserAgeLT(val *int) orm.Predicate {
-    return orm.C("Age").LT(val)
-}
-
-func UserAgeGT(val *int) orm.Predicate {
-    return orm.C("Age").GT(val)
-}
-
-func UserAgeEQ(val *int) orm.Predicate {
-    return orm.C("Age").EQ(val)
-}
-
-func UserNickNameLT(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").LT(val)
-}
-
-func UserNickNameGT(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").GT(val)
-}
-
-func UserNickNameEQ(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").EQ(val)
-}
-
-func UserPictureLT(val []byte) orm.Predicate {
-    return orm.C("Picture").LT(val)
-}
-
-func UserPictureGT(val []byte) orm.Predicate {
-    return orm.C("Picture").GT(val)
-}
-
-func UserPictureEQ(val []byte) orm.Predicate {
-    return orm.C("Picture").EQ(val)
-}
-
-func UserDetailAddressLT(val string) orm.Predicate {
-    return orm.C("Address").LT(val)
-}
-
-func UserDetailAddressGT(val string) orm.Predicate {
-    return orm.C("Address").GT(val)
-}
-
-func UserDetailAddressEQ(val string) orm.Predicate {
-    return orm.C("Address").EQ(val)
-}
-`, buffer.String())
-}
+	testCases := []struct {
+		name   string
+		gen    func(w io.Writer, srcFile string) error
+		golden string
+	}{
+		{
+			name:   "columns",
+			gen:    gen,
+			golden: "testdata/user.gen.go",
+		},
+		{
+			name:   "valuer",
+			gen:    genValuerFile,
+			golden: "testdata/user.valuer.gen.go",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			err := tc.gen(buffer, "testdata/user.go")
+			require.NoError(t, err)
+			want, err := os.ReadFile(tc.golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), buffer.String())
+		})
+	}
+}
+
+func Test_gen_invalidTag(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "model.go")
+	require.NoError(t, os.WriteFile(src, []byte("package model\n\ntype User struct {\n\tName string `orm:\"column\"`\n}\n"), 0644))
+	err := gen(io.Discard, src)
+	assert.EqualError(t, err, "orm: 错误的标签设置: column")
+}
+
+func Test_run(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("testdata/user.go")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user.go"), src, 0644))
+	// 测试文件和生成的文件都会被跳过
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "user_test.go"), []byte("package testdata\n\ntype T struct{}\n"), 0644))
 
-func Test_genFile(t *testing.T) {
-	f, err := os.Create("testdata/user.gen.go")
+	err = run(dir, true)
 	require.NoError(t, err)
-	err = gen(f, "testdata/user.go")
+	for _, name := range []string{"user.gen.go", "user.valuer.gen.go"} {
+		want, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got))
+	}
+	_, err = os.Stat(filepath.Join(dir, "user_test.gen.go"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 生成的文件已经存在的时候再跑一次也不会处理它们
+	err = run(dir, false)
 	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "user.gen.gen.go"))
+	assert.True(t, os.IsNotExist(err))
 }
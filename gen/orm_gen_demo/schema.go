@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Table 是从数据库里面读出来的表结构
+type Table struct {
+	Name    string
+	Columns []TableColumn
+}
+
+type TableColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+}
+
+// SchemaReader 读取数据库的表结构
+type SchemaReader interface {
+	// Tables 返回所有的表名
+	Tables(ctx context.Context) ([]string, error)
+	Table(ctx context.Context, name string) (Table, error)
+}
+
+func NewSchemaReader(driver string, db *sql.DB) (SchemaReader, error) {
+	switch driver {
+	case "sqlite3":
+		return sqliteSchema{db: db}, nil
+	case "mysql":
+		return mysqlSchema{db: db}, nil
+	default:
+		return nil, fmt.Errorf("orm: 不支持的数据库 %s", driver)
+	}
+}
+
+type sqliteSchema struct {
+	db *sql.DB
+}
+
+func (s sqliteSchema) Tables(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT `name` FROM `sqlite_master` WHERE `type` = 'table' AND `name` NOT LIKE 'sqlite_%' ORDER BY `name`;")
+	if err != nil {
+		return nil, err
+	}
+	return scanStrings(rows)
+}
+
+func (s sqliteSchema) Table(ctx context.Context, name string) (Table, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s);", s.quote(name)))
+	if err != nil {
+		return Table{}, err
+	}
+	defer rows.Close()
+	tbl := Table{Name: name}
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			col, typ         string
+			dflt             sql.NullString
+		)
+		if err = rows.Scan(&cid, &col, &typ, &notNull, &dflt, &pk); err != nil {
+			return Table{}, err
+		}
+		// SQLite 的 INTEGER PRIMARY KEY 是 rowid 的别名，不会是 NULL
+		tbl.Columns = append(tbl.Columns, TableColumn{Name: col, Type: typ, Nullable: notNull == 0 && pk == 0})
+	}
+	if err = rows.Err(); err != nil {
+		return Table{}, err
+	}
+	if len(tbl.Columns) == 0 {
+		return Table{}, fmt.Errorf("orm: 表 %s 不存在", name)
+	}
+	return tbl, nil
+}
+
+// quote 用反引号把标识符括起来，表名里面的反引号要写两次
+func (s sqliteSchema) quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+type mysqlSchema struct {
+	db *sql.DB
+}
+
+func (m mysqlSchema) Tables(ctx context.Context) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT `TABLE_NAME` FROM `information_schema`.`TABLES` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_TYPE` = 'BASE TABLE' ORDER BY `TABLE_NAME`;")
+	if err != nil {
+		return nil, err
+	}
+	return scanStrings(rows)
+}
+
+func (m mysqlSchema) Table(ctx context.Context, name string) (Table, error) {
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT `COLUMN_NAME`,`COLUMN_TYPE`,`IS_NULLABLE` FROM `information_schema`.`COLUMNS` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ? ORDER BY `ORDINAL_POSITION`;", name)
+	if err != nil {
+		return Table{}, err
+	}
+	defer rows.Close()
+	tbl := Table{Name: name}
+	for rows.Next() {
+		var col, typ, nullable string
+		if err = rows.Scan(&col, &typ, &nullable); err != nil {
+			return Table{}, err
+		}
+		tbl.Columns = append(tbl.Columns, TableColumn{Name: col, Type: typ, Nullable: nullable == "YES"})
+	}
+	if err = rows.Err(); err != nil {
+		return Table{}, err
+	}
+	if len(tbl.Columns) == 0 {
+		return Table{}, fmt.Errorf("orm: 表 %s 不存在", name)
+	}
+	return tbl, nil
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+// goType 把列的类型转换成 Go 类型，可以为 NULL 的列优先使用 sql.Null*，没有对应的就用指针
+func goType(col TableColumn) string {
+	typ := strings.ToLower(strings.TrimSpace(col.Type))
+	unsigned := strings.Contains(typ, "unsigned")
+	// MySQL 的 BOOL 就是 tinyint(1)
+	if strings.HasPrefix(typ, "tinyint(1)") {
+		typ = "bool"
+	}
+	if idx := strings.IndexAny(typ, "( "); idx >= 0 {
+		typ = typ[:idx]
+	}
+	var res, null string
+	switch typ {
+	case "tinyint":
+		res = "int8"
+	case "smallint":
+		res, null = "int16", "sql.NullInt16"
+	case "mediumint", "int":
+		res, null = "int32", "sql.NullInt32"
+	case "integer", "bigint":
+		res, null = "int64", "sql.NullInt64"
+	case "bool", "boolean":
+		res, null = "bool", "sql.NullBool"
+	case "float":
+		res = "float32"
+	case "real", "double", "decimal", "numeric":
+		res, null = "float64", "sql.NullFloat64"
+	case "char", "varchar", "nchar", "nvarchar", "text", "tinytext", "mediumtext", "longtext", "clob", "json", "enum", "set":
+		res, null = "string", "sql.NullString"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		// nil 的切片就是 NULL
+		return "[]byte"
+	case "date", "datetime", "timestamp":
+		res, null = "time.Time", "sql.NullTime"
+	default:
+		res = "any"
+	}
+	if unsigned && strings.HasPrefix(res, "int") {
+		res, null = "u"+res, ""
+	}
+	if !col.Nullable || res == "any" {
+		return res
+	}
+	if null != "" {
+		return null
+	}
+	return "*" + res
+}
+
+// camelName 字符串命名转驼峰，是 model 包里面 underscoreName 的逆过程
+func camelName(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	res := sb.String()
+	if res == "" || !unicode.IsLetter([]rune(res)[0]) {
+		res = "T" + res
+	}
+	return res
+}
+
+// schemaFile 把表结构转换成模板需要的数据
+func schemaFile(pkg string, tables []Table) *SchemaFile {
+	f := &SchemaFile{Package: pkg}
+	imports := make(map[string]struct{})
+	for _, tbl := range tables {
+		m := SchemaModel{Name: camelName(tbl.Name), Table: tbl.Name}
+		for _, col := range tbl.Columns {
+			typ := goType(col)
+			if strings.Contains(typ, "sql.") {
+				imports[`"database/sql"`] = struct{}{}
+			}
+			if strings.Contains(typ, "time.") {
+				imports[`"time"`] = struct{}{}
+			}
+			m.Fields = append(m.Fields, Field{Name: camelName(col.Name), Type: typ, Column: col.Name})
+		}
+		f.Models = append(f.Models, m)
+	}
+	for imp := range imports {
+		f.Imports = append(f.Imports, imp)
+	}
+	sort.Strings(f.Imports)
+	return f
+}
+
+type SchemaFile struct {
+	Package string
+	Imports []string
+	Models  []SchemaModel
+}
+
+type SchemaModel struct {
+	Name   string
+	Table  string
+	Fields []Field
+}
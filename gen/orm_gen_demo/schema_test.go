@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func Test_genModels(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:gen_models.db?cache=shared&mode=memory")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`
+CREATE TABLE user_account (
+    id INTEGER PRIMARY KEY,
+    user_name VARCHAR(64) NOT NULL,
+    nick_name TEXT,
+    age TINYINT,
+    balance DECIMAL(10,2) NOT NULL,
+    avatar BLOB,
+    created_at DATETIME NOT NULL,
+    deleted_at DATETIME
+);
+CREATE TABLE order_item (
+    order_id BIGINT NOT NULL,
+    sku VARCHAR(32) NOT NULL,
+    cnt INT
+);`)
+	require.NoError(t, err)
+	r, err := NewSchemaReader("sqlite3", db)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = genModels(context.Background(), buf, r, "schema")
+	require.NoError(t, err)
+	want, err := os.ReadFile("testdata/schema/model.gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+
+	err = genModels(context.Background(), buf, r, "schema", "not_exist")
+	assert.EqualError(t, err, "orm: 表 not_exist 不存在")
+}
+
+func Test_sqliteSchema_Table(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:sqlite_schema_table.db?cache=shared&mode=memory")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE `we``ird` (id INTEGER PRIMARY KEY);")
+	require.NoError(t, err)
+	r, err := NewSchemaReader("sqlite3", db)
+	require.NoError(t, err)
+
+	// 表名里面的反引号不能截断语句
+	tbl, err := r.Table(context.Background(), "we`ird")
+	require.NoError(t, err)
+	assert.Equal(t, Table{Name: "we`ird", Columns: []TableColumn{{Name: "id", Type: "INTEGER"}}}, tbl)
+	_, err = r.Table(context.Background(), "we`ird`); DROP TABLE `we``ird")
+	assert.EqualError(t, err, "orm: 表 we`ird`); DROP TABLE `we``ird 不存在")
+}
+
+func Test_goType(t *testing.T) {
+	testCases := []struct {
+		col  TableColumn
+		want string
+	}{
+		{col: TableColumn{Type: "INTEGER"}, want: "int64"},
+		{col: TableColumn{Type: "int(11)", Nullable: true}, want: "sql.NullInt32"},
+		{col: TableColumn{Type: "bigint(20) unsigned"}, want: "uint64"},
+		{col: TableColumn{Type: "bigint unsigned", Nullable: true}, want: "*uint64"},
+		{col: TableColumn{Type: "tinyint(1)", Nullable: true}, want: "sql.NullBool"},
+		{col: TableColumn{Type: "tinyint(1)"}, want: "bool"},
+		{col: TableColumn{Type: "tinyint(4)", Nullable: true}, want: "*int8"},
+		{col: TableColumn{Type: "float", Nullable: true}, want: "*float32"},
+		{col: TableColumn{Type: "varchar(255)"}, want: "string"},
+		{col: TableColumn{Type: "json", Nullable: true}, want: "sql.NullString"},
+		{col: TableColumn{Type: "varbinary(16)", Nullable: true}, want: "[]byte"},
+		{col: TableColumn{Type: "timestamp", Nullable: true}, want: "sql.NullTime"},
+		{col: TableColumn{Type: "geometry", Nullable: true}, want: "any"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.col.Type, func(t *testing.T) {
+			assert.Equal(t, tc.want, goType(tc.col))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Code generated by orm_gen_demo schema. DO NOT EDIT.
+
+package schema
+
+import (
+	"database/sql"
+	"time"
+)
+
+type OrderItem struct {
+	OrderId int64         `orm:"column=order_id"`
+	Sku     string        `orm:"column=sku"`
+	Cnt     sql.NullInt32 `orm:"column=cnt"`
+}
+
+func (OrderItem) TableName() string {
+	return "order_item"
+}
+
+type UserAccount struct {
+	Id        int64          `orm:"column=id"`
+	UserName  string         `orm:"column=user_name"`
+	NickName  sql.NullString `orm:"column=nick_name"`
+	Age       *int8          `orm:"column=age"`
+	Balance   float64        `orm:"column=balance"`
+	Avatar    []byte         `orm:"column=avatar"`
+	CreatedAt time.Time      `orm:"column=created_at"`
+	DeletedAt sql.NullTime   `orm:"column=deleted_at"`
+}
+
+func (UserAccount) TableName() string {
+	return "user_account"
+}
@@ -1,74 +1,55 @@
+// Code generated by orm_gen_demo. DO NOT EDIT.
+
 package testdata
 
 import (
-    "orm"
-    "database/sql"
-)
-
-const (
-   UserName = "Name"
-   UserAge = "Age"
-   UserNickName = "NickName"
-   UserPicture = "Picture"
-   UserDetailAddress = "Address"
+	"database/sql"
+	json2 "encoding/json"
+	"orm"
+	"time"
 )
 
-func UserNameLT(val string) orm.Predicate {
-    return orm.C("Name").LT(val)
-}
-
-func UserNameGT(val string) orm.Predicate {
-    return orm.C("Name").GT(val)
-}
-
-func UserNameEQ(val string) orm.Predicate {
-    return orm.C("Name").EQ(val)
-}
-
-func UserAgeLT(val *int) orm.Predicate {
-    return orm.C("Age").LT(val)
-}
-
-func UserAgeGT(val *int) orm.Predicate {
-    return orm.C("Age").GT(val)
-}
-
-func UserAgeEQ(val *int) orm.Predicate {
-    return orm.C("Age").EQ(val)
-}
-
-func UserNickNameLT(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").LT(val)
-}
-
-func UserNickNameGT(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").GT(val)
-}
-
-func UserNickNameEQ(val *sql.NullString) orm.Predicate {
-    return orm.C("NickName").EQ(val)
-}
-
-func UserPictureLT(val []byte) orm.Predicate {
-    return orm.C("Picture").LT(val)
-}
-
-func UserPictureGT(val []byte) orm.Predicate {
-    return orm.C("Picture").GT(val)
-}
-
-func UserPictureEQ(val []byte) orm.Predicate {
-    return orm.C("Picture").EQ(val)
-}
-
-func UserDetailAddressLT(val string) orm.Predicate {
-    return orm.C("Address").LT(val)
-}
-
-func UserDetailAddressGT(val string) orm.Predicate {
-    return orm.C("Address").GT(val)
-}
-
-func UserDetailAddressEQ(val string) orm.Predicate {
-    return orm.C("Address").EQ(val)
+// UserColumns 是 User 带类型的列
+var UserColumns = struct {
+	// Name 对应列 user_name
+	Name orm.TypedColumn[string]
+	// Age 对应列 age
+	Age orm.TypedColumn[*int]
+	// NickName 对应列 nick_name
+	NickName orm.TypedColumn[*sql.NullString]
+	// Picture 对应列 picture
+	Picture orm.TypedColumn[[]byte]
+	// Tags 对应列 tags
+	Tags orm.TypedColumn[[]string]
+	// Extra 对应列 extra
+	Extra orm.TypedColumn[map[string]json2.RawMessage]
+	// Birthday 对应列 birthday
+	Birthday orm.TypedColumn[time.Time]
+}{
+	Name:     orm.TypedC[string]("Name"),
+	Age:      orm.TypedC[*int]("Age"),
+	NickName: orm.TypedC[*sql.NullString]("NickName"),
+	Picture:  orm.TypedC[[]byte]("Picture"),
+	Tags:     orm.TypedC[[]string]("Tags"),
+	Extra:    orm.TypedC[map[string]json2.RawMessage]("Extra"),
+	Birthday: orm.TypedC[time.Time]("Birthday"),
+}
+
+// UserDetailColumns 是 UserDetail 带类型的列
+var UserDetailColumns = struct {
+	// Address 对应列 address
+	Address orm.TypedColumn[string]
+	// Base 对应列 base
+	Base orm.TypedColumn[Base]
+}{
+	Address: orm.TypedC[string]("Address"),
+	Base:    orm.TypedC[Base]("Base"),
+}
+
+// BaseColumns 是 Base 带类型的列
+var BaseColumns = struct {
+	// CreateTime 对应列 create_time
+	CreateTime orm.TypedColumn[int64]
+}{
+	CreateTime: orm.TypedC[int64]("CreateTime"),
 }
@@ -2,15 +2,36 @@ package testdata
 
 import (
 	"database/sql"
+	json2 "encoding/json"
+	"net/http"
+	"time"
 )
 
+//go:generate go run orm/gen/orm_gen_demo $GOFILE
+
 type User struct {
-	Name     string
+	Name     string `orm:"column=user_name"`
 	Age      *int
 	NickName *sql.NullString
 	Picture  []byte
+	Tags     []string
+	Extra    map[string]json2.RawMessage
+	Birthday time.Time
 }
 
 type UserDetail struct {
 	Address string
+	Base
+}
+
+type Base struct {
+	CreateTime int64
+}
+
+// Status 不是结构体，不会生成代码
+type Status int
+
+// client 用到的包不会出现在生成的文件里面
+func client() *http.Client {
+	return http.DefaultClient
 }
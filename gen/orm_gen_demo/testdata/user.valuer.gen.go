@@ -0,0 +1,205 @@
+// Code generated by orm_gen_demo. DO NOT EDIT.
+
+package testdata
+
+import (
+	"database/sql"
+	"orm"
+	"orm/model"
+)
+
+func init() {
+	orm.RegisterValuer[User](newUserValue)
+	orm.RegisterValuer[UserDetail](newUserDetailValue)
+	orm.RegisterValuer[Base](newBaseValue)
+}
+
+type userValue struct {
+	model         *model.Model
+	val           *User
+	ignoreUnknown bool
+}
+
+func newUserValue(m *model.Model, val *User) orm.Value {
+	return &userValue{
+		model: m,
+		val:   val,
+	}
+}
+
+func (v *userValue) IgnoreUnknownColumns() {
+	v.ignoreUnknown = true
+}
+
+func (v *userValue) Field(name string) (any, error) {
+	fd, ok := v.model.Fields[name]
+	if !ok {
+		return nil, orm.NewErrUnknownField(name)
+	}
+	switch name {
+	case "Name":
+		return fd.Value(v.val.Name)
+	case "Age":
+		return fd.Value(v.val.Age)
+	case "NickName":
+		return fd.Value(v.val.NickName)
+	case "Picture":
+		return fd.Value(v.val.Picture)
+	case "Tags":
+		return fd.Value(v.val.Tags)
+	case "Extra":
+		return fd.Value(v.val.Extra)
+	case "Birthday":
+		return fd.Value(v.val.Birthday)
+	}
+	return nil, orm.NewErrUnknownField(name)
+}
+
+func (v *userValue) SetColumns(rows *sql.Rows) error {
+	cs, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]any, 0, len(cs))
+	for _, c := range cs {
+		fd, ok := v.model.FieldByColumn(c)
+		if !ok {
+			if !v.ignoreUnknown {
+				return orm.NewErrUnknownColumn(c)
+			}
+			vals = append(vals, new(any))
+			continue
+		}
+		switch fd.GoName {
+		case "Name":
+			vals = append(vals, fd.Scanner(&v.val.Name))
+		case "Age":
+			vals = append(vals, fd.Scanner(&v.val.Age))
+		case "NickName":
+			vals = append(vals, fd.Scanner(&v.val.NickName))
+		case "Picture":
+			vals = append(vals, fd.Scanner(&v.val.Picture))
+		case "Tags":
+			vals = append(vals, fd.Scanner(&v.val.Tags))
+		case "Extra":
+			vals = append(vals, fd.Scanner(&v.val.Extra))
+		case "Birthday":
+			vals = append(vals, fd.Scanner(&v.val.Birthday))
+		default:
+			return orm.NewErrUnknownColumn(c)
+		}
+	}
+	return rows.Scan(vals...)
+}
+
+type userDetailValue struct {
+	model         *model.Model
+	val           *UserDetail
+	ignoreUnknown bool
+}
+
+func newUserDetailValue(m *model.Model, val *UserDetail) orm.Value {
+	return &userDetailValue{
+		model: m,
+		val:   val,
+	}
+}
+
+func (v *userDetailValue) IgnoreUnknownColumns() {
+	v.ignoreUnknown = true
+}
+
+func (v *userDetailValue) Field(name string) (any, error) {
+	fd, ok := v.model.Fields[name]
+	if !ok {
+		return nil, orm.NewErrUnknownField(name)
+	}
+	switch name {
+	case "Address":
+		return fd.Value(v.val.Address)
+	case "Base":
+		return fd.Value(v.val.Base)
+	}
+	return nil, orm.NewErrUnknownField(name)
+}
+
+func (v *userDetailValue) SetColumns(rows *sql.Rows) error {
+	cs, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]any, 0, len(cs))
+	for _, c := range cs {
+		fd, ok := v.model.FieldByColumn(c)
+		if !ok {
+			if !v.ignoreUnknown {
+				return orm.NewErrUnknownColumn(c)
+			}
+			vals = append(vals, new(any))
+			continue
+		}
+		switch fd.GoName {
+		case "Address":
+			vals = append(vals, fd.Scanner(&v.val.Address))
+		case "Base":
+			vals = append(vals, fd.Scanner(&v.val.Base))
+		default:
+			return orm.NewErrUnknownColumn(c)
+		}
+	}
+	return rows.Scan(vals...)
+}
+
+type baseValue struct {
+	model         *model.Model
+	val           *Base
+	ignoreUnknown bool
+}
+
+func newBaseValue(m *model.Model, val *Base) orm.Value {
+	return &baseValue{
+		model: m,
+		val:   val,
+	}
+}
+
+func (v *baseValue) IgnoreUnknownColumns() {
+	v.ignoreUnknown = true
+}
+
+func (v *baseValue) Field(name string) (any, error) {
+	fd, ok := v.model.Fields[name]
+	if !ok {
+		return nil, orm.NewErrUnknownField(name)
+	}
+	switch name {
+	case "CreateTime":
+		return fd.Value(v.val.CreateTime)
+	}
+	return nil, orm.NewErrUnknownField(name)
+}
+
+func (v *baseValue) SetColumns(rows *sql.Rows) error {
+	cs, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]any, 0, len(cs))
+	for _, c := range cs {
+		fd, ok := v.model.FieldByColumn(c)
+		if !ok {
+			if !v.ignoreUnknown {
+				return orm.NewErrUnknownColumn(c)
+			}
+			vals = append(vals, new(any))
+			continue
+		}
+		switch fd.GoName {
+		case "CreateTime":
+			vals = append(vals, fd.Scanner(&v.val.CreateTime))
+		default:
+			return orm.NewErrUnknownColumn(c)
+		}
+	}
+	return rows.Scan(vals...)
+}
@@ -0,0 +1,197 @@
+package orm
+
+import (
+	"context"
+	"net/url"
+	"orm/internal/errs"
+	"sort"
+	"strings"
+)
+
+type commentKey struct{}
+
+// indexHint 是 MySQL 的 USE INDEX 或者 FORCE INDEX
+type indexHint struct {
+	force   bool
+	indexes []string
+}
+
+// WithComment 在 ctx 上加上 SQL 注释，用这个 ctx 执行的所有语句都会带上，
+// 同一个 key 后面的值覆盖前面的
+func WithComment(ctx context.Context, key, value string) context.Context {
+	old, _ := ctx.Value(commentKey{}).(map[string]string)
+	res := make(map[string]string, len(old)+1)
+	for k, v := range old {
+		res[k] = v
+	}
+	res[key] = value
+	return context.WithValue(ctx, commentKey{}, res)
+}
+
+// DBWithCommenter 每条语句发给数据库之前调用 fn 获取注释，比如应用名和 traceparent。
+// 注释会以 sqlcommenter 的格式加在语句的最前面，中间件看到的 SQL 里面没有注释。
+// 注释每次都不一样的时候预编译语句缓存基本不会命中
+func DBWithCommenter(fn func(ctx context.Context) map[string]string) DBOptions {
+	return func(db *DB) {
+		db.commenter = fn
+	}
+}
+
+// hint 加上优化器提示，提示里面不能有 */
+func (b *builder) hint(hints []string) {
+	b.hints = append(b.hints, hints...)
+}
+
+func (b *builder) comment(key, value string) {
+	if b.comments == nil {
+		b.comments = make(map[string]string, 2)
+	}
+	b.comments[key] = value
+}
+
+// build 返回构造好的语句，优化器提示写在第一个关键字后面
+func (b *builder) build() (*Query, error) {
+	sql := b.sb.String()
+	if len(b.hints) > 0 {
+		for _, h := range b.hints {
+			if strings.Contains(h, "*/") {
+				return nil, errs.NewErrInvalidHint(h)
+			}
+		}
+		idx := strings.IndexByte(sql, ' ')
+		sql = sql[:idx] + " /*+ " + strings.Join(b.hints, " ") + " */" + sql[idx:]
+	}
+	return &Query{
+		SQL:      sql,
+		Args:     b.args,
+		Comments: b.comments,
+	}, nil
+}
+
+// comment 合并 DBWithCommenter、ctx 和语句上面的注释，后面的覆盖前面的
+func (c core) comment(ctx context.Context, q *Query) string {
+	var cs []map[string]string
+	if c.commenter != nil {
+		cs = append(cs, c.commenter(ctx))
+	}
+	if m, ok := ctx.Value(commentKey{}).(map[string]string); ok {
+		cs = append(cs, m)
+	}
+	cs = append(cs, q.Comments)
+	merged := make(map[string]string, 4)
+	for _, m := range cs {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	if len(merged) == 0 {
+		return ""
+	}
+	return renderComment(merged)
+}
+
+// renderComment 按照 sqlcommenter 的格式，键排序，键和值都要 URL 编码，值用单引号包起来
+func renderComment(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString("/*")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(commentEscape(k))
+		sb.WriteString("='")
+		sb.WriteString(commentEscape(m[k]))
+		sb.WriteByte('\'')
+	}
+	sb.WriteString("*/ ")
+	return sb.String()
+}
+
+// commentEscape / 也会被编码，所以注释里面不会出现 */
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// UseIndex 建议 MySQL 使用这些索引，SQLite 和 PostgreSQL 会忽略
+func (s *Selector[T]) UseIndex(idx ...string) *Selector[T] {
+	s.index = &indexHint{indexes: idx}
+	return s
+}
+
+// ForceIndex 强制使用索引，SQLite 只能指定一个索引，会使用 INDEXED BY 第一个索引
+func (s *Selector[T]) ForceIndex(idx ...string) *Selector[T] {
+	s.index = &indexHint{force: true, indexes: idx}
+	return s
+}
+
+// Hint 加上优化器提示，比如 MAX_EXECUTION_TIME(100)，会生成 SELECT /*+ MAX_EXECUTION_TIME(100) */ ...
+func (s *Selector[T]) Hint(hints ...string) *Selector[T] {
+	s.hint(hints)
+	return s
+}
+
+// Comment 给这条语句加上注释，执行的时候放在 SQL 最前面
+func (s *Selector[T]) Comment(key, value string) *Selector[T] {
+	s.comment(key, value)
+	return s
+}
+
+func (i *Inserter[T]) Hint(hints ...string) *Inserter[T] {
+	i.hint(hints)
+	return i
+}
+
+func (i *Inserter[T]) Comment(key, value string) *Inserter[T] {
+	i.comment(key, value)
+	return i
+}
+
+func (u *Updater[T]) Hint(hints ...string) *Updater[T] {
+	u.hint(hints)
+	return u
+}
+
+func (u *Updater[T]) Comment(key, value string) *Updater[T] {
+	u.comment(key, value)
+	return u
+}
+
+func (s *Deleter[T]) Hint(hints ...string) *Deleter[T] {
+	s.hint(hints)
+	return s
+}
+
+func (s *Deleter[T]) Comment(key, value string) *Deleter[T] {
+	s.comment(key, value)
+	return s
+}
+
+func (m mysqlDialect) buildIndexHint(b *builder, h indexHint) {
+	if h.force {
+		b.sb.WriteString(" FORCE INDEX (")
+	} else {
+		b.sb.WriteString(" USE INDEX (")
+	}
+	for i, idx := range h.indexes {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(idx)
+	}
+	b.sb.WriteByte(')')
+}
+
+func (s sqliteDialect) buildIndexHint(b *builder, h indexHint) {
+	if !h.force || len(h.indexes) == 0 {
+		return
+	}
+	b.sb.WriteString(" INDEXED BY ")
+	b.quote(h.indexes[0])
+}
+
+func (s standardSQL) buildIndexHint(b *builder, h indexHint) {}
@@ -0,0 +1,135 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestHint_Build(t *testing.T) {
+	db := memoryDB(t)
+	sqliteDB := memoryDB(t, DBWithDialect(DialectSQLite))
+	pgDB := memoryDB(t, DBWithDialect(DialectPostgreSQL))
+
+	testCases := []struct {
+		name     string
+		b        QueryBuilder
+		wantSQL  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:     "use index",
+			b:        NewSelector[TestModel](db).UseIndex("idx_age", "idx_name").Where(C("Age").GT(18)),
+			wantSQL:  "SELECT * FROM `test_model` USE INDEX (`idx_age`,`idx_name`) WHERE `age` > ?;",
+			wantArgs: []any{18},
+		},
+		{
+			name:    "force index with alias",
+			b:       NewSelector[TestModel](db).From(TableOf(&TestModel{}).As("t")).ForceIndex("idx_age"),
+			wantSQL: "SELECT * FROM `test_model` AS `t` FORCE INDEX (`idx_age`);",
+		},
+		{
+			name:    "sqlite force index",
+			b:       NewSelector[TestModel](sqliteDB).ForceIndex("idx_age"),
+			wantSQL: "SELECT * FROM `test_model` INDEXED BY `idx_age`;",
+		},
+		{
+			name:    "sqlite use index",
+			b:       NewSelector[TestModel](sqliteDB).UseIndex("idx_age"),
+			wantSQL: "SELECT * FROM `test_model`;",
+		},
+		{
+			name:    "postgres ignore index",
+			b:       NewSelector[TestModel](pgDB).ForceIndex("idx_age"),
+			wantSQL: `SELECT * FROM "test_model";`,
+		},
+		{
+			name: "index on join",
+			b: NewSelector[TestModel](db).ForceIndex("idx_age").
+				From(TableOf(&TestModel{}).Join(TableOf(&Post{})).Using("Id")),
+			wantErr: errs.ErrIndexHintTable,
+		},
+		{
+			name:    "select hint",
+			b:       NewSelector[TestModel](db).Hint("MAX_EXECUTION_TIME(100)", "NO_ICP(test_model)"),
+			wantSQL: "SELECT /*+ MAX_EXECUTION_TIME(100) NO_ICP(test_model) */ * FROM `test_model`;",
+		},
+		{
+			name:     "insert hint",
+			b:        NewInserter[TestModel](db).Hint("SET_VAR(foreign_key_checks=OFF)").Values(&TestModel{Id: 1}),
+			wantSQL:  "INSERT /*+ SET_VAR(foreign_key_checks=OFF) */ INTO `test_model`(`id`,`first_name`,`age`,`last_name`) VALUES (?,?,?,?);",
+			wantArgs: []any{int64(1), "", int8(0), sql.NullString{}},
+		},
+		{
+			name:     "update hint",
+			b:        NewUpdater[TestModel](db).Hint("MAX_EXECUTION_TIME(100)").Set(Assign("Age", 18)).Where(C("Id").EQ(1)),
+			wantSQL:  "UPDATE /*+ MAX_EXECUTION_TIME(100) */ `test_model` SET `age`=? WHERE `id` = ?;",
+			wantArgs: []any{18, 1},
+		},
+		{
+			name:     "delete hint",
+			b:        NewDeleter[TestModel](db).Hint("BKA(test_model)").Where(C("Id").EQ(1)),
+			wantSQL:  "DELETE /*+ BKA(test_model) */ FROM `test_model` WHERE `id` = ?;",
+			wantArgs: []any{1},
+		},
+		{
+			name:    "invalid hint",
+			b:       NewSelector[TestModel](db).Hint("a */ DROP TABLE x; /*"),
+			wantErr: errs.NewErrInvalidHint("a */ DROP TABLE x; /*"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.b.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Equal(t, tc.wantArgs, q.Args)
+		})
+	}
+}
+
+func TestComment(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB, DBWithCommenter(func(ctx context.Context) map[string]string {
+		return map[string]string{"application": "order-api", "route": "/orders/{id}"}
+	}))
+	require.NoError(t, err)
+
+	ctx := WithComment(context.Background(), "traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx = WithComment(ctx, "route", "/orders/list")
+
+	mock.ExpectQuery("/*action='get',application='order-api',route='%2Forders%2Flist'," +
+		"traceparent='00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01'*/ " +
+		"SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM `test_model` WHERE `id` = ?;").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	res, err := NewSelector[TestModel](db).Where(C("Id").EQ(1)).
+		Hint("MAX_EXECUTION_TIME(100)").Comment("action", "get").Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Id)
+
+	// 语句的注释不会出现在 SQL 里面，也就不影响中间件和缓存
+	q, err := NewDeleter[TestModel](db).Comment("who", "it's me */").Where(C("Id").EQ(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM `test_model` WHERE `id` = ?;", q.SQL)
+	mock.ExpectExec("/*application='order-api',route='%2Forders%2F%7Bid%7D',who='it%27s%20me%20%2A%2F'*/ " +
+		"DELETE FROM `test_model` WHERE `id` = ?;").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err := NewDeleter[TestModel](db).Comment("who", "it's me */").Where(C("Id").EQ(1)).
+		Exec(context.Background()).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
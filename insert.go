@@ -2,8 +2,10 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"orm/internal/errs"
 	"orm/model"
+	"strings"
 )
 
 type UpsertBuilder[T any] struct {
@@ -41,10 +43,19 @@ type Assignable interface {
 
 type Inserter[T any] struct {
 	val []*T
+	// source 不为空的时候构造 INSERT ... SELECT
+	source QueryBuilder
 	builder
 	columns        []string
 	onDuplicateKey *Upsert
 	tableName      string
+	// ignore 为 true 的时候冲突的行直接跳过
+	ignore bool
+	// batchSize 是 ExecBatch 每条语句最多插入的行数
+	batchSize int
+	// batchTx 为 true 的时候 ExecBatch 在一个事务里面执行
+	batchTx bool
+	txOpts  *sql.TxOptions
 }
 
 func NewInserter[T any](sess Session) *Inserter[T] {
@@ -59,27 +70,69 @@ func (i *Inserter[T]) Values(vals ...*T) *Inserter[T] {
 	return i
 }
 
+// ValuesFrom 插入查询的结果，也就是 INSERT INTO t(cols) SELECT ...，
+// q 查询的列要和 Columns 指定的列对应，没有指定 Columns 的时候是所有的列
+func (i *Inserter[T]) ValuesFrom(q QueryBuilder) *Inserter[T] {
+	i.source = q
+	return i
+}
+
 func (i *Inserter[T]) Columns(col ...string) *Inserter[T] {
 	i.columns = col
 	return i
 }
 
+// Ignore 冲突的行直接跳过，MySQL 是 INSERT IGNORE，SQLite 和 PostgreSQL 是 ON CONFLICT DO NOTHING
+func (i *Inserter[T]) Ignore() *Inserter[T] {
+	i.ignore = true
+	return i
+}
+
 func (i *Inserter[T]) Build() (*Query, error) {
-	if len(i.val) == 0 {
+	stmt, err := i.statement()
+	if err != nil {
+		return nil, err
+	}
+	return i.render(stmt)
+}
+
+// statement 插入语句只有 Model 和 Hints，租户字段在 render 的时候填充
+func (i *Inserter[T]) statement() (*Statement, error) {
+	i = i.Clone()
+	if len(i.val) > 0 && i.source != nil {
+		return nil, errs.ErrInsertBothSource
+	}
+	if len(i.val) == 0 && i.source == nil {
 		return nil, errs.ErrInsertZeroRow
 	}
-	i.sb.WriteString("INSERT INTO ")
+	if i.ignore && i.onDuplicateKey != nil {
+		return nil, errs.ErrIgnoreWithUpsert
+	}
 	if i.Model == nil {
 		var err error
-		i.Model, err = i.r.Get(i.val[0])
+		i.Model, err = i.r.Get(new(T))
 		if err != nil {
 			return nil, err
 		}
 	}
+	stmt := i.newStatement("INSERT")
+	return stmt, i.rewrite(stmt, i.tableName)
+}
+
+func (i *Inserter[T]) render(stmt *Statement) (*Query, error) {
+	i = i.Clone()
+	if err := i.apply(stmt); err != nil {
+		return nil, err
+	}
+	verb, suffix := "INSERT INTO ", ""
+	if i.ignore {
+		verb, suffix = i.dialect.insertIgnore()
+	}
+	i.sb.WriteString(verb)
 	if len(i.tableName) != 0 {
 		i.sb.WriteString(i.tableName)
 	} else {
-		i.quote(i.Model.TableName)
+		i.quote(i.builder.tableName(i.Model.TableName))
 	}
 	i.sb.WriteByte('(')
 	fields := i.Model.FieldArr
@@ -93,6 +146,21 @@ func (i *Inserter[T]) Build() (*Query, error) {
 			fields = append(fields, fd)
 		}
 	}
+	// INSERT ... SELECT 的查询本身会被隔离，所以只处理 VALUES
+	var tenant *model.Field
+	var tenantVal any
+	if i.source == nil {
+		val, ok, err := i.tenantValue()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tenant, tenantVal = i.Model.Fields[i.tenant.field], val
+			if !containsField(fields, tenant) {
+				fields = append(fields, tenant)
+			}
+		}
+	}
 	for index, f := range fields {
 		if index > 0 {
 			i.sb.WriteByte(',')
@@ -100,7 +168,17 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		i.quote(f.ColName)
 	}
 	i.sb.WriteByte(')')
-	i.sb.WriteString(" VALUES ")
+	if i.source != nil {
+		q, err := bindContext(i.ctx, i.source).Build()
+		if err != nil {
+			return nil, err
+		}
+		i.sb.WriteByte(' ')
+		i.sb.WriteString(strings.TrimSuffix(q.SQL, ";"))
+		i.addArg(q.Args...)
+	} else {
+		i.sb.WriteString(" VALUES ")
+	}
 	for j, v := range i.val {
 		if j > 0 {
 			i.sb.WriteByte(',')
@@ -112,6 +190,10 @@ func (i *Inserter[T]) Build() (*Query, error) {
 				i.sb.WriteByte(',')
 			}
 			i.sb.WriteByte('?')
+			if field == tenant {
+				i.addArg(tenantVal)
+				continue
+			}
 			arg, err := val.Field(field.GoName)
 			if err != nil {
 				return nil, err
@@ -121,16 +203,32 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		i.sb.WriteByte(')')
 	}
 	if i.onDuplicateKey != nil {
+		if err := i.checkTenantAssigns(i.onDuplicateKey.assigns); err != nil {
+			return nil, err
+		}
 		err := i.dialect.buildUpsert(&i.builder, i.onDuplicateKey)
 		if err != nil {
 			return nil, err
 		}
 	}
+	i.sb.WriteString(suffix)
 	i.sb.WriteByte(';')
-	return &Query{
-		SQL:  i.sb.String(),
-		Args: i.args,
-	}, nil
+	return i.build()
+}
+
+// Unscoped 不使用多租户隔离，插入的时候使用实体自己的租户字段
+func (i *Inserter[T]) Unscoped() *Inserter[T] {
+	i.unscoped = true
+	return i
+}
+
+func containsField(fields []*model.Field, fd *model.Field) bool {
+	for _, f := range fields {
+		if f == fd {
+			return true
+		}
+	}
+	return false
 }
 
 func (i *Inserter[T]) From(tableName string) *Inserter[T] {
@@ -151,6 +151,21 @@ func TestInserter_Build(t *testing.T) {
 				Args: []any{int64(12), "Tom", int8(18), sql.NullString{String: "Jerry", Valid: true}},
 			},
 		},
+		{
+			name: "insert select",
+			i: NewInserter[TestModel](db).Columns("Id", "FirstName").
+				ValuesFrom(NewSelector[TestModel](db).Select(C("Age"), C("LastName")).Where(C("Id").GT(10))),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model`(`id`,`first_name`) SELECT `age`,`last_name` FROM `test_model` WHERE `id` > ?;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "insert select and values",
+			i: NewInserter[TestModel](db).Values(&TestModel{}).
+				ValuesFrom(NewSelector[TestModel](db)),
+			wantErr: errs.ErrInsertBothSource,
+		},
 	}
 	for _, tc := range testCase {
 		t.Run(tc.name, func(t *testing.T) {
@@ -6,10 +6,31 @@ import (
 )
 
 var (
-	ErrPointerOnly   = errors.New("")
-	ErrNoRows        = errors.New("orm: 没有数据")
-	ErrAliasWhere    = errors.New("orm: where条件不能用别名")
-	ErrInsertZeroRow = errors.New("orm: 插入0行")
+	ErrPointerOnly        = errors.New("")
+	ErrNoRows             = errors.New("orm: 没有数据")
+	ErrAliasWhere         = errors.New("orm: where条件不能用别名")
+	ErrInsertZeroRow      = errors.New("orm: 插入0行")
+	ErrEmptySliceArg      = errors.New("orm: 切片参数不能为空")
+	ErrIgnoreWithUpsert   = errors.New("orm: Ignore 不能和 OnDuplicateKey 一起使用")
+	ErrNoUpdatedColumns   = errors.New("orm: 没有要更新的列")
+	ErrUpdateNoEntity     = errors.New("orm: 用列更新的时候必须通过 Update 指定实体")
+	ErrEntityNotTracked   = errors.New("orm: 实体没有被跟踪，需要通过 DB.Tracked 查询")
+	ErrMissingTenant      = errors.New("orm: ctx 里面没有租户")
+	ErrTenantAssign       = errors.New("orm: 多租户隔离的时候不能修改租户字段")
+	ErrInvalidPage        = errors.New("orm: page 和 size 必须大于 0")
+	ErrMissingKeyProvider = errors.New("orm: 没有设置 aes 的密钥，需要调用 model.SetKeyProvider")
+	ErrInvalidCiphertext  = errors.New("orm: 密文长度不对")
+	ErrInvalidCursor      = errors.New("orm: 无效的游标")
+	ErrExplainNoSession   = errors.New("orm: 语句还没有开始执行，无法 EXPLAIN")
+	ErrIndexHintTable     = errors.New("orm: 索引提示只能用在单个表上")
+	ErrNoStatement        = errors.New("orm: 原生查询和建表语句没有 Statement")
+	ErrShadowDisabled     = errors.New("orm: 压测流量需要配置影子表或者影子库")
+	ErrShadowRaw          = errors.New("orm: 影子表模式下不能执行原生查询，也不能使用 From 指定的表名")
+	ErrShadowTx           = errors.New("orm: 压测流量不能使用正式库上的事务")
+	// ErrCursorOrder 游标分页需要 ORDER BY，并且所有列的排序方向一致
+	ErrCursorOrder      = errors.New("orm: 游标分页需要方向一致的 ORDER BY")
+	ErrJoinTarget       = errors.New("orm: JOIN 最左边的表必须是要修改的表")
+	ErrInsertBothSource = errors.New("orm: Values 和 ValuesFrom 只能使用一个")
 )
 
 func NewErrUnSupportType(expr any) error {
@@ -40,6 +61,50 @@ func NewErrUnSupportAssignable(expr any) error {
 	return fmt.Errorf("orm: 不支持的赋值表达式类型: %v", expr)
 }
 
+func NewErrUnSupportedJoin(typ string) error {
+	return fmt.Errorf("orm: UPDATE 和 DELETE 里面要修改的表不支持 %s", typ)
+}
+
 func NewErrUnSupportedTable(expr any) error {
 	return fmt.Errorf("orm: 不支持的TableReference类型: %v", expr)
 }
+
+func NewErrUnknownSerializer(name string) error {
+	return fmt.Errorf("orm: 未知的序列化方式 %s", name)
+}
+
+func NewErrInvalidJSONPath(path string) error {
+	return fmt.Errorf("orm: 不支持的 JSON 路径 %s", path)
+}
+
+func NewErrUnsupportedSource(src any) error {
+	return fmt.Errorf("orm: 不支持从 %T 转换", src)
+}
+
+func NewErrUnsupportedAESType(val any) error {
+	return fmt.Errorf("orm: aes 不支持 %T", val)
+}
+
+func NewErrUnstablePredicate(field string) error {
+	return fmt.Errorf("orm: %s 的转换结果不稳定，不能用在查询条件里面", field)
+}
+
+func NewErrUnsupportedJSON(expr any) error {
+	return fmt.Errorf("orm: 方言不支持 JSON 表达式 %v", expr)
+}
+
+func NewErrUnsupportedColumnType(typ any) error {
+	return fmt.Errorf("orm: 无法推断 %v 的列类型，需要使用 type 标签", typ)
+}
+
+func NewErrInvalidHint(hint string) error {
+	return fmt.Errorf("orm: 优化器提示里面不能有 */: %s", hint)
+}
+
+func NewErrRawArgsMismatch(cnt int) error {
+	return fmt.Errorf("orm: 占位符和参数数量不一致，参数数量: %d", cnt)
+}
+
+func NewErrUnKnownNamedArg(name string) error {
+	return fmt.Errorf("orm: 未知的命名参数 %s", name)
+}
@@ -0,0 +1,25 @@
+// Code generated by orm_gen_demo. DO NOT EDIT.
+
+package test
+
+import (
+	"database/sql"
+	"orm"
+)
+
+// ValuerModelColumns 是 ValuerModel 带类型的列
+var ValuerModelColumns = struct {
+	// Id 对应列 id
+	Id orm.TypedColumn[int64]
+	// FirstName 对应列 first_name
+	FirstName orm.TypedColumn[string]
+	// Age 对应列 age
+	Age orm.TypedColumn[int8]
+	// LastName 对应列 last_name
+	LastName orm.TypedColumn[sql.NullString]
+}{
+	Id:        orm.TypedC[int64]("Id"),
+	FirstName: orm.TypedC[string]("FirstName"),
+	Age:       orm.TypedC[int8]("Age"),
+	LastName:  orm.TypedC[sql.NullString]("LastName"),
+}
@@ -0,0 +1,13 @@
+package test
+
+import "database/sql"
+
+//go:generate go run orm/gen/orm_gen_demo $GOFILE
+
+// ValuerModel 用来测试和压测生成的 Value，字段和 valuer 包里面的 TestModel 一样
+type ValuerModel struct {
+	Id        int64
+	FirstName string
+	Age       int8
+	LastName  sql.NullString
+}
@@ -0,0 +1,79 @@
+// Code generated by orm_gen_demo. DO NOT EDIT.
+
+package test
+
+import (
+	"database/sql"
+	"orm"
+	"orm/model"
+)
+
+func init() {
+	orm.RegisterValuer[ValuerModel](newValuerModelValue)
+}
+
+type valuerModelValue struct {
+	model         *model.Model
+	val           *ValuerModel
+	ignoreUnknown bool
+}
+
+func newValuerModelValue(m *model.Model, val *ValuerModel) orm.Value {
+	return &valuerModelValue{
+		model: m,
+		val:   val,
+	}
+}
+
+func (v *valuerModelValue) IgnoreUnknownColumns() {
+	v.ignoreUnknown = true
+}
+
+func (v *valuerModelValue) Field(name string) (any, error) {
+	fd, ok := v.model.Fields[name]
+	if !ok {
+		return nil, orm.NewErrUnknownField(name)
+	}
+	switch name {
+	case "Id":
+		return fd.Value(v.val.Id)
+	case "FirstName":
+		return fd.Value(v.val.FirstName)
+	case "Age":
+		return fd.Value(v.val.Age)
+	case "LastName":
+		return fd.Value(v.val.LastName)
+	}
+	return nil, orm.NewErrUnknownField(name)
+}
+
+func (v *valuerModelValue) SetColumns(rows *sql.Rows) error {
+	cs, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]any, 0, len(cs))
+	for _, c := range cs {
+		fd, ok := v.model.FieldByColumn(c)
+		if !ok {
+			if !v.ignoreUnknown {
+				return orm.NewErrUnknownColumn(c)
+			}
+			vals = append(vals, new(any))
+			continue
+		}
+		switch fd.GoName {
+		case "Id":
+			vals = append(vals, fd.Scanner(&v.val.Id))
+		case "FirstName":
+			vals = append(vals, fd.Scanner(&v.val.FirstName))
+		case "Age":
+			vals = append(vals, fd.Scanner(&v.val.Age))
+		case "LastName":
+			vals = append(vals, fd.Scanner(&v.val.LastName))
+		default:
+			return orm.NewErrUnknownColumn(c)
+		}
+	}
+	return rows.Scan(vals...)
+}
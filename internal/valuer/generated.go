@@ -0,0 +1,35 @@
+package valuer
+
+import (
+	"orm/model"
+	"reflect"
+	"sync"
+)
+
+// generated 保存生成代码注册的 Creator，键是实体指针的类型
+var generated sync.Map
+
+// Register 注册 typ 对应的 Creator，typ 是实体的指针类型。
+// 一般由生成的代码在 init 里面调用
+func Register(typ reflect.Type, c Creator) {
+	generated.Store(typ, c)
+}
+
+// Generated 返回 typ 注册过的 Creator
+func Generated(typ reflect.Type) (Creator, bool) {
+	c, ok := generated.Load(typ)
+	if !ok {
+		return nil, false
+	}
+	return c.(Creator), true
+}
+
+// PreferGenerated 包装 c，实体有生成的 Creator 的时候优先使用，没有的时候才退回到 c
+func PreferGenerated(c Creator) Creator {
+	return func(model *model.Model, entity any) Value {
+		if g, ok := Generated(reflect.TypeOf(entity)); ok {
+			return g(model, entity)
+		}
+		return c(model, entity)
+	}
+}
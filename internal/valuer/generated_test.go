@@ -0,0 +1,111 @@
+package valuer_test
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+	"orm/internal/test"
+	"orm/internal/valuer"
+	"orm/model"
+	"reflect"
+	"testing"
+)
+
+func TestPreferGenerated(t *testing.T) {
+	testCases := []struct {
+		name       string
+		creator    valuer.Creator
+		entity     any
+		rows       *sqlmock.Rows
+		wantErr    error
+		wantEntity any
+	}{
+		{
+			name:    "generated",
+			creator: valuer.PreferGenerated(valuer.NewReflectValue),
+			entity:  &test.ValuerModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+				rows.AddRow(1, "Tom", 18, "Jerry")
+				return rows
+			}(),
+			wantEntity: &test.ValuerModel{
+				Id:        1,
+				FirstName: "Tom",
+				Age:       18,
+				LastName:  sql.NullString{Valid: true, String: "Jerry"},
+			},
+		},
+		{
+			name:    "unknown column",
+			creator: valuer.PreferGenerated(valuer.NewReflectValue),
+			entity:  &test.ValuerModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "cnt"})
+				rows.AddRow(1, 10)
+				return rows
+			}(),
+			wantErr: errs.NewErrUnKnownColumn("cnt"),
+		},
+		{
+			name:    "ignore unknown column",
+			creator: valuer.IgnoreUnknownColumns(valuer.PreferGenerated(valuer.NewReflectValue)),
+			entity:  &test.ValuerModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "cnt"})
+				rows.AddRow(1, 10)
+				return rows
+			}(),
+			wantEntity: &test.ValuerModel{Id: 1},
+		},
+		{
+			name:    "fallback",
+			creator: valuer.PreferGenerated(valuer.NewReflectValue),
+			entity:  &test.SimpleStruct{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id"})
+				rows.AddRow(1)
+				return rows
+			}(),
+			wantEntity: &test.SimpleStruct{Id: 1},
+		},
+	}
+
+	r := model.NewRegistry()
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT XX").WillReturnRows(tc.rows)
+			rows, err := mockDB.Query("SELECT XX")
+			require.NoError(t, err)
+			defer rows.Close()
+			require.True(t, rows.Next())
+			m, err := r.Get(tc.entity)
+			require.NoError(t, err)
+			err = tc.creator(m, tc.entity).SetColumns(rows)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantEntity, tc.entity)
+		})
+	}
+}
+
+func TestGenerated_Field(t *testing.T) {
+	creator, ok := valuer.Generated(reflect.TypeOf(&test.ValuerModel{}))
+	require.True(t, ok)
+	m, err := model.NewRegistry().Get(&test.ValuerModel{})
+	require.NoError(t, err)
+	val := creator(m, &test.ValuerModel{Id: 1, FirstName: "Tom"})
+
+	res, err := val.Field("FirstName")
+	require.NoError(t, err)
+	assert.Equal(t, "Tom", res)
+	_, err = val.Field("Invalid")
+	assert.Equal(t, errs.NewErrUnKnownField("Invalid"), err)
+}
@@ -8,8 +8,9 @@ import (
 )
 
 type reflectValue struct {
-	model *model.Model
-	val   reflect.Value
+	model         *model.Model
+	val           reflect.Value
+	ignoreUnknown bool
 }
 
 var _ Creator = NewReflectValue
@@ -21,8 +22,16 @@ func NewReflectValue(model *model.Model, val any) Value {
 	}
 }
 
+func (r *reflectValue) IgnoreUnknownColumns() {
+	r.ignoreUnknown = true
+}
+
 func (r *reflectValue) Field(name string) (any, error) {
-	return r.val.FieldByName(name).Interface(), nil
+	fd, ok := r.model.Fields[name]
+	if !ok {
+		return nil, errs.NewErrUnKnownField(name)
+	}
+	return fd.Value(r.val.FieldByName(name).Interface())
 }
 
 func (r *reflectValue) SetColumns(rows *sql.Rows) error {
@@ -32,23 +41,30 @@ func (r *reflectValue) SetColumns(rows *sql.Rows) error {
 	}
 	vals := make([]any, 0, len(cs))
 	valElems := make([]reflect.Value, 0, len(cs))
+	fds := make([]*model.Field, 0, len(cs))
 	for _, c := range cs {
-		fd, ok := r.model.Columns[c]
+		fd, ok := r.model.FieldByColumn(c)
 		if !ok {
-			return errs.NewErrUnKnownColumn(c)
+			if !r.ignoreUnknown {
+				return errs.NewErrUnKnownColumn(c)
+			}
+			vals = append(vals, new(any))
+			valElems = append(valElems, reflect.Value{})
+			fds = append(fds, nil)
+			continue
 		}
 		val := reflect.New(fd.Typ)
-		vals = append(vals, val.Interface())
+		vals = append(vals, fd.Scanner(val.Interface()))
 		valElems = append(valElems, val.Elem())
+		fds = append(fds, fd)
 	}
 	err = rows.Scan(vals...)
 	if err != nil {
 		return err
 	}
-	for i, c := range cs {
-		fd, ok := r.model.Columns[c]
-		if !ok {
-			return errs.NewErrUnKnownColumn(c)
+	for i, fd := range fds {
+		if fd == nil {
+			continue
 		}
 		r.val.FieldByName(fd.GoName).Set(valElems[i])
 	}
@@ -5,6 +5,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
 	"orm/model"
 	"testing"
 )
@@ -56,6 +57,29 @@ func SetColumns(t *testing.T, creator Creator) {
 				LastName: sql.NullString{Valid: true, String: "Jerry"},
 			},
 		},
+		{
+			name:   "go field name",
+			entity: &TestModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"Id", "FirstName"})
+				rows.AddRow(1, "Tom")
+				return rows
+			}(),
+			wantEntity: &TestModel{
+				Id:        1,
+				FirstName: "Tom",
+			},
+		},
+		{
+			name:   "unknown column",
+			entity: &TestModel{},
+			rows: func() *sqlmock.Rows {
+				rows := sqlmock.NewRows([]string{"id", "cnt"})
+				rows.AddRow(1, 10)
+				return rows
+			}(),
+			wantErr: errs.NewErrUnKnownColumn("cnt"),
+		},
 	}
 	r := model.NewRegistry()
 	mockDB, mock, err := sqlmock.New()
@@ -81,3 +105,29 @@ func SetColumns(t *testing.T, creator Creator) {
 		})
 	}
 }
+
+func TestIgnoreUnknownColumns(t *testing.T) {
+	creators := map[string]Creator{
+		"reflect": NewReflectValue,
+		"unsafe":  NewUnsafeValue,
+	}
+	r := model.NewRegistry()
+	m, err := r.Get(&TestModel{})
+	require.NoError(t, err)
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	for name, creator := range creators {
+		t.Run(name, func(t *testing.T) {
+			entity := &TestModel{}
+			val := IgnoreUnknownColumns(creator)(m, entity)
+			mock.ExpectQuery("SELECT XXX").WillReturnRows(
+				sqlmock.NewRows([]string{"id", "cnt", "first_name"}).AddRow(1, 10, "Tom"))
+			rows, err := mockDB.Query("SELECT XXX")
+			require.NoError(t, err)
+			rows.Next()
+			require.NoError(t, val.SetColumns(rows))
+			assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, entity)
+		})
+	}
+}
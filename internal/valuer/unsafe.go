@@ -9,8 +9,9 @@ import (
 )
 
 type unsafeValue struct {
-	model   *model.Model
-	address unsafe.Pointer
+	model         *model.Model
+	address       unsafe.Pointer
+	ignoreUnknown bool
 }
 
 var _ Creator = NewUnsafeValue
@@ -23,6 +24,10 @@ func NewUnsafeValue(model *model.Model, val any) Value {
 	}
 }
 
+func (r *unsafeValue) IgnoreUnknownColumns() {
+	r.ignoreUnknown = true
+}
+
 func (r *unsafeValue) Field(name string) (any, error) {
 	fd, ok := r.model.Fields[name]
 	if !ok {
@@ -30,7 +35,7 @@ func (r *unsafeValue) Field(name string) (any, error) {
 	}
 	fdAdress := unsafe.Pointer(uintptr(r.address) + fd.Offset)
 	val := reflect.NewAt(fd.Typ, fdAdress)
-	return val.Elem().Interface(), nil
+	return fd.Value(val.Elem().Interface())
 }
 
 func (r *unsafeValue) SetColumns(rows *sql.Rows) error {
@@ -42,12 +47,16 @@ func (r *unsafeValue) SetColumns(rows *sql.Rows) error {
 	var vals []any
 
 	for _, c := range cs {
-		fd, ok := r.model.Columns[c]
+		fd, ok := r.model.FieldByColumn(c)
 		if !ok {
-			return errs.NewErrUnKnownColumn(c)
+			if !r.ignoreUnknown {
+				return errs.NewErrUnKnownColumn(c)
+			}
+			vals = append(vals, new(any))
+			continue
 		}
 		val := reflect.NewAt(fd.Typ, unsafe.Pointer(uintptr(r.address)+fd.Offset))
-		vals = append(vals, val.Interface())
+		vals = append(vals, fd.Scanner(val.Interface()))
 	}
 	return rows.Scan(vals...)
 }
@@ -11,3 +11,19 @@ type Value interface {
 }
 
 type Creator func(model *model.Model, entity any) Value
+
+// IgnoreUnknownColumns 包装 c，创建出来的 Value 遇到模型里面没有的列时直接丢弃，而不是返回错误
+func IgnoreUnknownColumns(c Creator) Creator {
+	return func(model *model.Model, entity any) Value {
+		val := c(model, entity)
+		if i, ok := val.(unknownColumnsIgnorer); ok {
+			i.IgnoreUnknownColumns()
+		}
+		return val
+	}
+}
+
+// unknownColumnsIgnorer 的方法是导出的，生成的代码在别的包里面也能实现它
+type unknownColumnsIgnorer interface {
+	IgnoreUnknownColumns()
+}
@@ -1,15 +1,18 @@
-package valuer
+package valuer_test
 
 import (
 	"database/sql/driver"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
+	"orm/internal/test"
+	"orm/internal/valuer"
 	"orm/model"
+	"reflect"
 	"testing"
 )
 
 func BenchmarkSetColumns(b *testing.B) {
-	fn := func(b *testing.B, creator Creator) {
+	fn := func(b *testing.B, creator valuer.Creator) {
 		mockDB, mock, err := sqlmock.New()
 		require.NoError(b, err)
 		defer mockDB.Close()
@@ -22,19 +25,24 @@ func BenchmarkSetColumns(b *testing.B) {
 		rows, err := mockDB.Query("SELECT XXx")
 		require.NoError(b, err)
 		r := model.NewRegistry()
-		model, err := r.Get(&TestModel{})
+		model, err := r.Get(&test.ValuerModel{})
 		require.NoError(b, err)
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
 			rows.Next()
-			val := creator(model, &TestModel{})
+			val := creator(model, &test.ValuerModel{})
 			_ = val.SetColumns(rows)
 		}
 	}
 	b.Run("reflect", func(b *testing.B) {
-		fn(b, NewReflectValue)
+		fn(b, valuer.NewReflectValue)
 	})
 	b.Run("unsafe", func(b *testing.B) {
-		fn(b, NewUnsafeValue)
+		fn(b, valuer.NewUnsafeValue)
+	})
+	b.Run("generated", func(b *testing.B) {
+		creator, ok := valuer.Generated(reflect.TypeOf(&test.ValuerModel{}))
+		require.True(b, ok)
+		fn(b, creator)
 	})
 }
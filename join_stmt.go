@@ -0,0 +1,78 @@
+package orm
+
+import "orm/internal/errs"
+
+// joinStmt 是带 JOIN 的 UPDATE 和 DELETE，各个方言的写法差别很大，交给方言来构造
+type joinStmt struct {
+	// target 是要修改的表，也就是 JOIN 最左边的表
+	target Table
+	join   Join
+	where  []Predicate
+	// set 构造 UPDATE 的 SET 部分，qualify 为 true 的时候列名前面带上表名或者别名
+	set func(qualify bool) error
+}
+
+func (b *builder) newJoinStmt(j Join, where []Predicate) (joinStmt, error) {
+	left := j.left
+	for {
+		l, ok := left.(Join)
+		if !ok {
+			break
+		}
+		left = l.left
+	}
+	target, ok := left.(Table)
+	if !ok {
+		return joinStmt{}, errs.ErrJoinTarget
+	}
+	if err := b.checkTarget(target); err != nil {
+		return joinStmt{}, err
+	}
+	return joinStmt{target: target, join: j, where: where}, nil
+}
+
+// checkTarget 确认 t 就是 b.Model 对应的表
+func (b *builder) checkTarget(t Table) error {
+	m, err := b.r.Get(t.entity)
+	if err != nil {
+		return err
+	}
+	if m.TableName != b.Model.TableName {
+		return errs.ErrJoinTarget
+	}
+	return nil
+}
+
+// buildTargetName 写入要修改的表的别名，没有别名的时候写入表名
+func (b *builder) buildTargetName(t Table) {
+	if t.alias != "" {
+		b.quote(t.alias)
+		return
+	}
+	b.quote(b.tableName(b.Model.TableName))
+}
+
+// splitJoin 把 a JOIN b ON p1 JOIN c ON p2 拆成 a JOIN b ON p1 和 b JOIN c ON p2，
+// 用于 UPDATE ... FROM 和 DELETE ... USING，p1 会被放到 WHERE 里面
+func splitJoin(j Join) (Join, TableReference, error) {
+	if l, ok := j.left.(Join); ok {
+		first, rest, err := splitJoin(l)
+		if err != nil {
+			return Join{}, nil, err
+		}
+		return first, Join{left: rest, right: j.right, typ: j.typ, on: j.on, using: j.using}, nil
+	}
+	if j.typ != "JOIN" || len(j.using) > 0 {
+		return Join{}, nil, errs.NewErrUnSupportedJoin(j.typ)
+	}
+	return j, j.right, nil
+}
+
+// buildWhere 构造 WHERE，ps 为空的时候什么都不写
+func (b *builder) buildWhere(ps []Predicate) error {
+	if len(ps) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" WHERE ")
+	return b.buildPredicates(ps)
+}
@@ -0,0 +1,186 @@
+package orm
+
+import (
+	"encoding/json"
+	"orm/internal/errs"
+	"regexp"
+	"strings"
+)
+
+type jsonFn string
+
+const (
+	jsonExtract     jsonFn = "EXTRACT"
+	jsonArrayLength jsonFn = "ARRAY_LENGTH"
+)
+
+// jsonPathPattern 只支持 $.a.b 和 $.a[0] 这种路径，这样 MySQL 可以直接把路径写进 SQL
+var jsonPathPattern = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+])*$`)
+
+// JSONExpr 是 JSON 列里面某个路径上的值，可以出现在 SELECT 和 WHERE 里面。
+// PostgreSQL 的 ->> 返回的是文本，和数字比较的时候需要自己用 Raw 转换类型
+type JSONExpr struct {
+	fn    jsonFn
+	col   Column
+	path  string
+	alias string
+}
+
+// JSONExtract 取出 col 在 path 上的值，path 是 $.a.b 这种形式
+func JSONExtract(col string, path string) JSONExpr {
+	return JSONExpr{fn: jsonExtract, col: C(col), path: path}
+}
+
+// JSONArrayLength 返回 col 在 path 上的数组的长度
+func JSONArrayLength(col string, path string) JSONExpr {
+	return JSONExpr{fn: jsonArrayLength, col: C(col), path: path}
+}
+
+func (j JSONExpr) expr() {}
+
+func (j JSONExpr) selectedAlias() string {
+	return j.alias
+}
+
+func (j JSONExpr) fieldName() string {
+	return ""
+}
+
+func (j JSONExpr) As(alias string) JSONExpr {
+	j.alias = alias
+	return j
+}
+
+func (j JSONExpr) EQ(arg any) Predicate {
+	return Predicate{left: j, op: opEq, right: valueOf(arg)}
+}
+
+func (j JSONExpr) NEQ(arg any) Predicate {
+	return Predicate{left: j, op: opNEQ, right: valueOf(arg)}
+}
+
+func (j JSONExpr) LT(arg any) Predicate {
+	return Predicate{left: j, op: opLT, right: valueOf(arg)}
+}
+
+func (j JSONExpr) GT(arg any) Predicate {
+	return Predicate{left: j, op: opGT, right: valueOf(arg)}
+}
+
+func (j JSONExpr) In(vals ...any) Predicate {
+	return Predicate{left: j, op: opIN, right: values{values: vals}}
+}
+
+// jsonContains 是 JSONContains 的条件
+type jsonContains struct {
+	col Column
+	val any
+}
+
+func (j jsonContains) expr() {}
+
+// JSONContains 判断 col 是否包含 val，val 可以是对象、数组或者数组里面的一个元素。
+// SQLite 只支持 val 是基本类型，判断数组里面有没有这个元素
+func JSONContains(col string, val any) Predicate {
+	return Predicate{left: jsonContains{col: C(col), val: val}}
+}
+
+// JSONAssignment 通过 JSON_SET 修改 JSON 列里面的某些路径
+type JSONAssignment struct {
+	col   Column
+	paths []string
+	vals  []any
+}
+
+func (j JSONAssignment) assign() {}
+
+// JSONSet 把 col 在 path 上的值设置为 val，不存在的时候会创建。
+// 同一列要修改多个路径的时候用 And，而不是多个 JSONSet
+func JSONSet(col string, path string, val any) JSONAssignment {
+	return JSONAssignment{col: C(col), paths: []string{path}, vals: []any{val}}
+}
+
+// And 在同一个 JSON_SET 里面再修改一个路径
+func (j JSONAssignment) And(path string, val any) JSONAssignment {
+	return JSONAssignment{
+		col:   j.col,
+		paths: append(j.paths[:len(j.paths):len(j.paths)], path),
+		vals:  append(j.vals[:len(j.vals):len(j.vals)], val),
+	}
+}
+
+// jsonSegments 把 $.a[0] 拆成 a 和 0
+func jsonSegments(path string) ([]string, error) {
+	if !jsonPathPattern.MatchString(path) {
+		return nil, errs.NewErrInvalidJSONPath(path)
+	}
+	res := make([]string, 0, 4)
+	for _, seg := range strings.FieldsFunc(path[1:], func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	}) {
+		res = append(res, seg)
+	}
+	return res, nil
+}
+
+// jsonArg 把 val 序列化成 JSON 文本，[]byte 和 json.RawMessage 认为已经是 JSON 了
+func jsonArg(val any) (string, error) {
+	switch v := val.(type) {
+	case json.RawMessage:
+		return string(v), nil
+	case []byte:
+		return string(v), nil
+	}
+	bs, err := json.Marshal(val)
+	return string(bs), err
+}
+
+// isJSONScalar 基本类型可以直接作为 JSON_SET 的参数，其它的需要先转成 JSON
+func isJSONScalar(val any) bool {
+	switch val.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// buildJSONCall 构造 fn(col,?)，path 作为参数
+func (b *builder) buildJSONCall(fn string, col Column, path string) error {
+	b.sb.WriteString(fn)
+	if err := b.buildColumn(col); err != nil {
+		return err
+	}
+	b.sb.WriteString(",?)")
+	b.addArg(path)
+	return nil
+}
+
+// buildJSONSet 构造 fn(col,?,?,...)，val 不是基本类型的时候用 wrap 把 JSON 文本转换成 JSON
+func (b *builder) buildJSONSet(fn string, a JSONAssignment, wrap string) error {
+	b.sb.WriteString(fn)
+	if err := b.buildColumn(a.col); err != nil {
+		return err
+	}
+	for i, path := range a.paths {
+		if _, err := jsonSegments(path); err != nil {
+			return err
+		}
+		b.sb.WriteString(",?,")
+		b.addArg(path)
+		if isJSONScalar(a.vals[i]) {
+			b.sb.WriteByte('?')
+			b.addArg(a.vals[i])
+			continue
+		}
+		arg, err := jsonArg(a.vals[i])
+		if err != nil {
+			return err
+		}
+		b.sb.WriteString(wrap)
+		b.addArg(arg)
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
@@ -0,0 +1,149 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type FeatureFlag struct {
+	Id     int64
+	Name   string
+	Config map[string]any `orm:"serializer=json"`
+}
+
+func TestJSON_Build(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		q       func(db *DB) QueryBuilder
+		wantSQL string
+		args    []any
+		wantErr error
+	}{
+		{
+			name:    "mysql extract",
+			dialect: DialectMySQL,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Select(C("Name"), JSONExtract("Config", "$.rollout.percent").As("percent")).
+					Where(JSONExtract("Config", "$.env").EQ("prod"))
+			},
+			wantSQL: "SELECT `name`,`config`->>'$.rollout.percent' AS `percent` FROM `feature_flag` WHERE `config`->>'$.env' = ?;",
+			args:    []any{"prod"},
+		},
+		{
+			name:    "mysql contains and length",
+			dialect: DialectMySQL,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Where(JSONContains("Config", map[string]any{"beta": true}),
+					JSONArrayLength("Config", "$.users").GT(2))
+			},
+			wantSQL: "SELECT * FROM `feature_flag` WHERE (JSON_CONTAINS(`config`,?)) AND (JSON_LENGTH(`config`,?) > ?);",
+			args:    []any{`{"beta":true}`, "$.users", 2},
+		},
+		{
+			name:    "mysql set",
+			dialect: DialectMySQL,
+			q: func(db *DB) QueryBuilder {
+				return NewUpdater[FeatureFlag](db).Set(JSONSet("Config", "$.env", "dev").
+					And("$.users", []string{"tom"})).Where(C("Id").EQ(1))
+			},
+			wantSQL: "UPDATE `feature_flag` SET `config`=JSON_SET(`config`,?,?,?,CAST(? AS JSON)) WHERE `id` = ?;",
+			args:    []any{"$.env", "dev", "$.users", `["tom"]`, 1},
+		},
+		{
+			name:    "sqlite",
+			dialect: DialectSQLite,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Where(JSONExtract("Config", "$.env").EQ("prod"),
+					JSONContains("Config", "beta"), JSONArrayLength("Config", "$.users[0]").EQ(1))
+			},
+			wantSQL: "SELECT * FROM `feature_flag` WHERE ((json_extract(`config`,?) = ?) AND (EXISTS (SELECT 1 FROM json_each(`config`) WHERE value=?))) AND (json_array_length(`config`,?) = ?);",
+			args:    []any{"$.env", "prod", "beta", "$.users[0]", 1},
+		},
+		{
+			name:    "sqlite contains object",
+			dialect: DialectSQLite,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Where(JSONContains("Config", map[string]any{"beta": true}))
+			},
+			wantErr: errs.NewErrUnsupportedJSON(map[string]any{"beta": true}),
+		},
+		{
+			name:    "postgres",
+			dialect: DialectPostgreSQL,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Where(JSONExtract("Config", "$.env").EQ("prod"),
+					JSONExtract("Config", "$.rollout.percent").EQ("10"), JSONContains("Config", map[string]any{"beta": true}))
+			},
+			wantSQL: `SELECT * FROM "feature_flag" WHERE (("config"->>? = ?) AND ("config"#>>?::text[] = ?)) AND ("config" @> ?::jsonb);`,
+			args:    []any{"env", "prod", "{rollout,percent}", "10", `{"beta":true}`},
+		},
+		{
+			name:    "postgres length and set",
+			dialect: DialectPostgreSQL,
+			q: func(db *DB) QueryBuilder {
+				return NewUpdater[FeatureFlag](db).Set(JSONSet("Config", "$.users[1]", "tom").And("$.env", "dev")).
+					Where(JSONArrayLength("Config", "$").GT(1))
+			},
+			wantSQL: `UPDATE "feature_flag" SET "config"=jsonb_set(jsonb_set("config",?::text[],?::jsonb),?::text[],?::jsonb) WHERE jsonb_array_length("config") > ?;`,
+			args:    []any{"{users,1}", `"tom"`, "{env}", `"dev"`, 1},
+		},
+		{
+			name:    "invalid path",
+			dialect: DialectMySQL,
+			q: func(db *DB) QueryBuilder {
+				return NewSelector[FeatureFlag](db).Where(JSONExtract("Config", "$.a' OR 1=1").EQ(1))
+			},
+			wantErr: errs.NewErrInvalidJSONPath("$.a' OR 1=1"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			q, err := tc.q(db).Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Equal(t, tc.args, q.Args)
+		})
+	}
+}
+
+func TestJSON_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:json.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec("CREATE TABLE `feature_flag`(`id` INTEGER PRIMARY KEY, `name` TEXT, `config` TEXT)")
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, NewInserter[FeatureFlag](db).Values(
+		&FeatureFlag{Id: 1, Name: "search", Config: map[string]any{"env": "prod", "users": []any{"tom", "jerry"}}},
+		&FeatureFlag{Id: 2, Name: "pay", Config: map[string]any{"env": "dev", "users": []any{"tom"}}},
+	).Exec(ctx).Err())
+
+	res, err := NewSelector[FeatureFlag](db).Where(JSONExtract("Config", "$.env").EQ("prod")).GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "search", res[0].Name)
+
+	names, err := Scan[string](ctx, NewSelector[FeatureFlag](db).Select(C("Name")).
+		Where(JSONArrayLength("Config", "$.users").GT(1)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"search"}, names)
+
+	require.NoError(t, NewUpdater[FeatureFlag](db).Set(JSONSet("Config", "$.env", "prod").
+		And("$.limits", map[string]any{"qps": 10})).Where(C("Id").EQ(2)).Exec(ctx).Err())
+	flag, err := NewSelector[FeatureFlag](db).Where(C("Id").EQ(2)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"env": "prod", "users": []any{"tom"}, "limits": map[string]any{"qps": float64(10)}}, flag.Config)
+
+	cnt, err := NewSelector[FeatureFlag](db).Where(JSONExtract("Config", "$.env").EQ("prod")).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+}
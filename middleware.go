@@ -2,13 +2,87 @@ package orm
 
 import (
 	"context"
+	"orm/internal/errs"
 	"orm/model"
+	"reflect"
+	"time"
 )
 
 type QueryContext struct {
 	Type    string
 	Builder QueryBuilder
 	Model   *model.Model
+	// ResultType 查询结果的类型，Get 返回的是指向它的指针
+	ResultType reflect.Type
+
+	q    *Query
+	stmt *Statement
+	ctx  context.Context
+	// sess 和 core 是路由之后执行语句的 Session 和配置，Explain 需要用到
+	sess Session
+	core core
+}
+
+// Context 返回执行语句的 ctx
+func (qc *QueryContext) Context() context.Context {
+	if qc.ctx == nil {
+		return context.Background()
+	}
+	return qc.ctx
+}
+
+// Query 返回构造好的查询，同一个 QueryContext 只会构造一次，
+// 中间件和最终执行查询的地方拿到的是同一个 Query
+func (qc *QueryContext) Query() (*Query, error) {
+	if qc.q != nil {
+		return qc.q, nil
+	}
+	var (
+		q   *Query
+		err error
+	)
+	if b, ok := qc.Builder.(statementBuilder); ok {
+		var stmt *Statement
+		if stmt, err = qc.Statement(); err != nil {
+			return nil, err
+		}
+		q, err = b.render(stmt)
+	} else {
+		q, err = qc.Builder.Build()
+	}
+	if err != nil {
+		return nil, err
+	}
+	qc.q = q
+	return q, nil
+}
+
+// Statement 返回还没有生成 SQL 的语句，同一个 QueryContext 只会构造一次。
+// 在 Query 之前修改 Statement 会影响最终的 SQL，原生查询和建表语句返回 errs.ErrNoStatement
+func (qc *QueryContext) Statement() (*Statement, error) {
+	if qc.stmt != nil {
+		return qc.stmt, nil
+	}
+	b, ok := qc.Builder.(statementBuilder)
+	if !ok {
+		return nil, errs.ErrNoStatement
+	}
+	stmt, err := b.statement()
+	if err != nil {
+		return nil, err
+	}
+	qc.stmt = stmt
+	return stmt, nil
+}
+
+// Tables 返回语句用到的所有表，包括 JOIN、ON 和 WHERE 里面的子查询用到的表，
+// 是替换之后的表名，比如压测流量的影子表。查询缓存用它决定缓存键和写入之后要失效的表
+func (qc *QueryContext) Tables() ([]string, error) {
+	stmt, err := qc.Statement()
+	if err != nil {
+		return nil, err
+	}
+	return stmt.allTables(qc.Context(), make([]string, 0, 2))
 }
 
 type QueryResult struct {
@@ -19,3 +93,17 @@ type QueryResult struct {
 type Handler func(ctx context.Context, qc *QueryContext) *QueryResult
 
 type Middleware func(next Handler) Handler
+
+type queryCacheKey struct{}
+
+// WithQueryCache 标记 ctx 上的查询结果可以被缓存 ttl 这么久，
+// 需要配合 middleware 里面的查询缓存中间件使用
+func WithQueryCache(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, queryCacheKey{}, ttl)
+}
+
+// QueryCacheTTL 返回 ctx 上标记的缓存时间
+func QueryCacheTTL(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(queryCacheKey{}).(time.Duration)
+	return ttl, ok && ttl > 0
+}
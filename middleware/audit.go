@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"orm"
+	"time"
+)
+
+type actorKey struct{}
+
+// WithActor 在 ctx 上记录当前的操作人，审计日志会从这里取
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom 返回 WithActor 设置的操作人
+func ActorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// AuditRecord 一条审计日志，记录谁在什么时候改了哪张表
+type AuditRecord struct {
+	Actor        string
+	Type         string
+	Table        string
+	SQL          string
+	Args         []any
+	RowsAffected int64
+	Err          error
+	Time         time.Time
+}
+
+type auditBuilder struct {
+	actorFunc func(ctx context.Context) string
+	logFunc   func(ctx context.Context, r AuditRecord)
+}
+
+// NewAuditBuilder 记录所有修改数据的语句，查询语句不会记录
+func NewAuditBuilder() *auditBuilder {
+	return &auditBuilder{
+		actorFunc: ActorFrom,
+		logFunc: func(ctx context.Context, r AuditRecord) {
+			log.Printf("audit: actor=%s type=%s table=%s rows_affected=%d sql=%s args=%v err=%v",
+				r.Actor, r.Type, r.Table, r.RowsAffected, r.SQL, r.Args, r.Err)
+		},
+	}
+}
+
+// ActorFunc 设置从 ctx 里面获取操作人的方法，默认使用 ActorFrom
+func (b *auditBuilder) ActorFunc(fn func(ctx context.Context) string) *auditBuilder {
+	b.actorFunc = fn
+	return b
+}
+
+func (b *auditBuilder) LogFunc(fn func(ctx context.Context, r AuditRecord)) *auditBuilder {
+	b.logFunc = fn
+	return b
+}
+
+func (b *auditBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			res := next(ctx, qc)
+			// 只有通过 Exec 执行的语句才会返回 orm.Result，RAW 语句也是这样区分的
+			if _, ok := res.Result.(orm.Result); !ok {
+				return res
+			}
+			r := AuditRecord{
+				Actor:        b.actorFunc(ctx),
+				Type:         qc.Type,
+				Table:        tableName(qc),
+				RowsAffected: rowsAffected(res),
+				Err:          resultErr(res),
+				Time:         time.Now(),
+			}
+			if q, err := qc.Query(); err == nil {
+				r.SQL, r.Args = q.SQL, q.Args
+			}
+			b.logFunc(ctx, r)
+			return res
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestAuditBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	var records []AuditRecord
+	m := NewAuditBuilder().LogFunc(func(ctx context.Context, r AuditRecord) {
+		records = append(records, r)
+	})
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(m.Build()))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("DELETE .*").WillReturnError(errors.New("exec error"))
+
+	ctx := WithActor(context.Background(), "Tom")
+	_, err = orm.NewSelector[TestModel](db).Get(ctx)
+	require.NoError(t, err)
+	require.NoError(t, orm.NewInserter[TestModel](db).Values(&TestModel{Id: 12}).Exec(ctx).Err())
+	err = orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(12)).Exec(ctx).Err()
+	assert.Equal(t, errors.New("exec error"), err)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "Tom", records[0].Actor)
+	assert.Equal(t, "INSERT", records[0].Type)
+	assert.Equal(t, "test_model", records[0].Table)
+	assert.Equal(t, int64(1), records[0].RowsAffected)
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, "DELETE", records[1].Type)
+	assert.Equal(t, []any{12}, records[1].Args)
+	assert.Equal(t, errors.New("exec error"), records[1].Err)
+}
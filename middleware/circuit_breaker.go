@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"orm"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("orm: 熔断器已打开，拒绝执行查询")
+
+const (
+	stateClosed = iota
+	stateOpen
+	stateHalfOpen
+)
+
+type circuitBreakerBuilder struct {
+	window        time.Duration
+	minRequests   int
+	errorRate     float64
+	slowThreshold time.Duration
+	openTimeout   time.Duration
+	halfOpenMax   int
+	clock         Clock
+}
+
+// NewCircuitBreakerBuilder 按照 类型+表 统计错误率和响应时间，
+// 错误率过高的时候打开熔断器直接返回 ErrCircuitOpen，
+// 过了 OpenTimeout 之后进入半开状态，放少量请求去探测数据库是否恢复
+func NewCircuitBreakerBuilder() *circuitBreakerBuilder {
+	return &circuitBreakerBuilder{
+		window:      10 * time.Second,
+		minRequests: 20,
+		errorRate:   0.5,
+		openTimeout: 5 * time.Second,
+		halfOpenMax: 1,
+		clock:       realClock{},
+	}
+}
+
+// Window 统计错误率的时间窗口
+func (b *circuitBreakerBuilder) Window(window time.Duration) *circuitBreakerBuilder {
+	b.window = window
+	return b
+}
+
+// MinRequests 一个窗口内至少要有这么多请求才会计算错误率
+func (b *circuitBreakerBuilder) MinRequests(n int) *circuitBreakerBuilder {
+	b.minRequests = n
+	return b
+}
+
+// ErrorRate 错误率达到这个值的时候打开熔断器
+func (b *circuitBreakerBuilder) ErrorRate(rate float64) *circuitBreakerBuilder {
+	b.errorRate = rate
+	return b
+}
+
+// SlowThreshold 执行时间超过这个值的查询也算作失败，0 表示不考虑响应时间
+func (b *circuitBreakerBuilder) SlowThreshold(threshold time.Duration) *circuitBreakerBuilder {
+	b.slowThreshold = threshold
+	return b
+}
+
+// OpenTimeout 熔断器打开之后多久进入半开状态
+func (b *circuitBreakerBuilder) OpenTimeout(timeout time.Duration) *circuitBreakerBuilder {
+	b.openTimeout = timeout
+	return b
+}
+
+// HalfOpenRequests 半开状态下允许的探测请求数，这些请求全部成功之后熔断器关闭
+func (b *circuitBreakerBuilder) HalfOpenRequests(n int) *circuitBreakerBuilder {
+	b.halfOpenMax = n
+	return b
+}
+
+// Clock 设置时钟，主要用于测试
+func (b *circuitBreakerBuilder) Clock(clock Clock) *circuitBreakerBuilder {
+	b.clock = clock
+	return b
+}
+
+func (b *circuitBreakerBuilder) Build() orm.Middleware {
+	var breakers sync.Map
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			key := qc.Type + ":" + tableName(qc)
+			val, _ := breakers.LoadOrStore(key, &breaker{circuitBreakerBuilder: b, windowStart: b.clock.Now()})
+			cb := val.(*breaker)
+			tk, err := cb.allow()
+			if err != nil {
+				return &orm.QueryResult{Err: err}
+			}
+			startTime := b.clock.Now()
+			res := next(ctx, qc)
+			err = resultErr(res)
+			if errors.Is(err, context.Canceled) {
+				// 调用方主动取消不能说明数据库有没有恢复
+				cb.release(tk)
+				return res
+			}
+			failed := isFailure(err)
+			if b.slowThreshold > 0 && b.clock.Now().Sub(startTime) >= b.slowThreshold {
+				failed = true
+			}
+			cb.done(tk, failed)
+			return res
+		}
+	}
+}
+
+// isFailure 没有数据不是数据库的问题
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, orm.ErrNoRows)
+}
+
+type breaker struct {
+	*circuitBreakerBuilder
+	mutex sync.Mutex
+	state int
+	// generation 每次切换状态都会加一，用来识别切换之前放进来的请求
+	generation uint64
+
+	windowStart time.Time
+	total       int
+	failures    int
+
+	openedAt         time.Time
+	halfOpenInflight int
+	halfOpenSuccess  int
+}
+
+// token 记录请求是在哪个状态放进来的
+type token struct {
+	generation uint64
+	probe      bool
+}
+
+// setState 切换状态，之前放进来的请求完成的时候不再统计
+func (c *breaker) setState(state int, now time.Time) {
+	c.state = state
+	c.generation++
+	switch state {
+	case stateClosed:
+		c.windowStart, c.total, c.failures = now, 0, 0
+	case stateOpen:
+		c.openedAt = now
+	case stateHalfOpen:
+		c.halfOpenInflight, c.halfOpenSuccess = 0, 0
+	}
+}
+
+func (c *breaker) allow() (token, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	switch c.state {
+	case stateOpen:
+		now := c.clock.Now()
+		if now.Sub(c.openedAt) < c.openTimeout {
+			return token{}, ErrCircuitOpen
+		}
+		c.setState(stateHalfOpen, now)
+		fallthrough
+	case stateHalfOpen:
+		if c.halfOpenInflight >= c.halfOpenMax {
+			return token{}, ErrCircuitOpen
+		}
+		c.halfOpenInflight++
+		return token{generation: c.generation, probe: true}, nil
+	}
+	return token{generation: c.generation}, nil
+}
+
+// done 只统计当前状态下放进来的请求，状态切换之前的请求直接忽略
+func (c *breaker) done(tk token, failed bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if tk.generation != c.generation {
+		return
+	}
+	now := c.clock.Now()
+	switch c.state {
+	case stateClosed:
+		if now.Sub(c.windowStart) >= c.window {
+			c.windowStart, c.total, c.failures = now, 0, 0
+		}
+		c.total++
+		if failed {
+			c.failures++
+		}
+		if c.total >= c.minRequests && float64(c.failures)/float64(c.total) >= c.errorRate {
+			c.setState(stateOpen, now)
+		}
+	case stateHalfOpen:
+		if !tk.probe {
+			return
+		}
+		c.halfOpenInflight--
+		if failed {
+			c.setState(stateOpen, now)
+			return
+		}
+		c.halfOpenSuccess++
+		if c.halfOpenSuccess >= c.halfOpenMax {
+			c.setState(stateClosed, now)
+		}
+	}
+}
+
+// release 归还探测的名额，不统计结果也不切换状态
+func (c *breaker) release(tk token) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if tk.generation == c.generation && tk.probe {
+		c.halfOpenInflight--
+	}
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+	"orm/model"
+)
+
+// fakeClock 只有调用 Advance 的时候时间才会往前走
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	// step 每次调用 Now 之后自动往前走的时间
+	step   time.Duration
+	timers []*fakeTimer
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	res := f.now
+	f.now = f.now.Add(f.step)
+	return res
+}
+
+func (f *fakeClock) NewTimer(d time.Duration) Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1), at: f.now.Add(d)}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = f.now.Add(d)
+	timers := f.timers[:0]
+	for _, t := range f.timers {
+		if t.at.After(f.now) {
+			timers = append(timers, t)
+			continue
+		}
+		t.c <- f.now
+	}
+	f.timers = timers
+}
+
+// Timers 返回还没有触发也没有停止的定时器的数量
+func (f *fakeClock) Timers() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.timers)
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	c     chan time.Time
+	at    time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func TestCircuitBreakerBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	clock := &fakeClock{now: time.Now()}
+	m := NewCircuitBreakerBuilder().MinRequests(2).ErrorRate(0.5).
+		OpenTimeout(time.Second).Clock(clock)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(m.Build()))
+	require.NoError(t, err)
+	get := func() error {
+		_, err := orm.NewSelector[TestModel](db).Get(context.Background())
+		return err
+	}
+	dbErr := errors.New("bad connection")
+
+	mock.ExpectQuery("SELECT .*").WillReturnError(dbErr)
+	mock.ExpectQuery("SELECT .*").WillReturnError(dbErr)
+	assert.Equal(t, dbErr, get())
+	assert.Equal(t, dbErr, get())
+
+	// 错误率达到阈值，熔断器打开，不会再访问数据库
+	assert.Equal(t, ErrCircuitOpen, get())
+	// 其它表或者其它类型的语句不受影响
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, orm.NewDeleter[TestModel](db).Exec(context.Background()).Err())
+
+	// 半开状态，探测失败之后重新打开
+	clock.Advance(time.Second)
+	mock.ExpectQuery("SELECT .*").WillReturnError(dbErr)
+	assert.Equal(t, dbErr, get())
+	assert.Equal(t, ErrCircuitOpen, get())
+
+	// 探测成功之后关闭
+	clock.Advance(time.Second)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	assert.NoError(t, get())
+	assert.NoError(t, get())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCircuitBreakerBuilder_SlowThreshold(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	// 每次读时间都往前走 100ms，查询就都变成了慢查询
+	clock := &fakeClock{now: time.Now(), step: 100 * time.Millisecond}
+	m := NewCircuitBreakerBuilder().MinRequests(1).SlowThreshold(50 * time.Millisecond).Clock(clock)
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(m.Build()))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	_, err = orm.NewSelector[TestModel](db).Get(context.Background())
+	require.NoError(t, err)
+	_, err = orm.NewSelector[TestModel](db).Get(context.Background())
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBreaker_StaleDone(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	b := NewCircuitBreakerBuilder().MinRequests(1).OpenTimeout(time.Second).Clock(clock)
+	cb := &breaker{circuitBreakerBuilder: b, windowStart: clock.Now()}
+
+	slow, err := cb.allow()
+	require.NoError(t, err)
+	failed, err := cb.allow()
+	require.NoError(t, err)
+	cb.done(failed, true)
+	assert.Equal(t, stateOpen, cb.state)
+
+	clock.Advance(time.Second)
+	probe, err := cb.allow()
+	require.NoError(t, err)
+	assert.True(t, probe.probe)
+	// 打开之前放进来的请求现在才结束，不能影响半开状态
+	cb.done(slow, false)
+	assert.Equal(t, stateHalfOpen, cb.state)
+	assert.Equal(t, 1, cb.halfOpenInflight)
+	_, err = cb.allow()
+	assert.Equal(t, ErrCircuitOpen, err)
+
+	cb.done(probe, false)
+	assert.Equal(t, stateClosed, cb.state)
+	// 重复的完成也会被忽略
+	cb.done(probe, true)
+	assert.Equal(t, stateClosed, cb.state)
+	assert.Equal(t, 0, cb.failures)
+}
+
+func TestCircuitBreakerBuilder_Canceled(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	m := NewCircuitBreakerBuilder().MinRequests(2).OpenTimeout(time.Second).Clock(clock)
+	var resErr error
+	h := m.Build()(func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+		return &orm.QueryResult{Err: resErr}
+	})
+	qc := &orm.QueryContext{Type: "SELECT", Model: &model.Model{TableName: "test_model"}}
+	call := func(err error) error {
+		resErr = err
+		return h(context.Background(), qc).Err
+	}
+	dbErr := errors.New("bad connection")
+
+	assert.Equal(t, dbErr, call(dbErr))
+	assert.Equal(t, dbErr, call(dbErr))
+	assert.Equal(t, ErrCircuitOpen, call(nil))
+
+	// 取消的探测归还名额，熔断器还是半开
+	clock.Advance(time.Second)
+	assert.Equal(t, context.Canceled, call(context.Canceled))
+	assert.Equal(t, dbErr, call(dbErr))
+	assert.Equal(t, ErrCircuitOpen, call(nil))
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import "time"
+
+// Clock 是熔断和限流使用的时钟，测试的时候可以换成手动推进的时钟
+type Clock interface {
+	Now() time.Time
+	// NewTimer 和 time.NewTimer 一样，d 之后往 C 里面发送当前时间
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{Timer: time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
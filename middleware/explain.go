@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"math/rand"
+	"orm"
+)
+
+type explainSamplerBuilder struct {
+	rate    float64
+	rand    func() float64
+	onPlan  func(ctx context.Context, qc *orm.QueryContext, plan *orm.Plan)
+	onError func(ctx context.Context, qc *orm.QueryContext, err error)
+}
+
+// NewExplainSamplerBuilder 按照 rate 的比例对执行成功的 SELECT 做 EXPLAIN，
+// 执行计划里面有全表扫描或者额外排序的时候调用 onPlan。
+// EXPLAIN 是在查询返回之后同步执行的，rate 在 0 到 1 之间，线上一般设置得比较小
+func NewExplainSamplerBuilder(rate float64,
+	onPlan func(ctx context.Context, qc *orm.QueryContext, plan *orm.Plan)) *explainSamplerBuilder {
+	return &explainSamplerBuilder{
+		rate:    rate,
+		rand:    rand.Float64,
+		onPlan:  onPlan,
+		onError: func(ctx context.Context, qc *orm.QueryContext, err error) {},
+	}
+}
+
+// OnError 设置 EXPLAIN 失败的时候的回调，默认忽略，不影响查询的结果
+func (b *explainSamplerBuilder) OnError(fn func(ctx context.Context, qc *orm.QueryContext, err error)) *explainSamplerBuilder {
+	b.onError = fn
+	return b
+}
+
+func (b *explainSamplerBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			res := next(ctx, qc)
+			if qc.Type != "SELECT" || res.Err != nil || b.rand() >= b.rate {
+				return res
+			}
+			plan, err := qc.Explain(ctx)
+			if err != nil {
+				b.onError(ctx, qc, err)
+				return res
+			}
+			if plan.FullScan() || plan.Filesort() {
+				b.onPlan(ctx, qc, plan)
+			}
+			return res
+		}
+	}
+}
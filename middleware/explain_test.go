@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestExplainSamplerBuilder_Build(t *testing.T) {
+	var plans []*orm.Plan
+	b := NewExplainSamplerBuilder(0.5, func(ctx context.Context, qc *orm.QueryContext, plan *orm.Plan) {
+		plans = append(plans, plan)
+	})
+	samples := []float64{0.1, 0.9, 0.2, 0.3}
+	b.rand = func() float64 {
+		res := samples[0]
+		samples = samples[1:]
+		return res
+	}
+	var explainErr error
+	b.OnError(func(ctx context.Context, qc *orm.QueryContext, err error) {
+		explainErr = err
+	})
+	db, err := orm.Open("sqlite3", "file:explain_sampler.db?cache=shared&mode=memory",
+		orm.DBWithDialect(orm.DialectSQLite), orm.DBWithMiddleware(b.Build()))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	_, err = orm.RawQuery[any](db, "CREATE TABLE `test_model`(`id` INTEGER PRIMARY KEY, "+
+		"`first_name` TEXT, `age` INTEGER, `last_name` TEXT)").Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+
+	// 失败的查询不采样
+	_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(ctx)
+	assert.Equal(t, orm.ErrNoRows, err)
+	// 0.1 被采样，但是走的是主键，不会回调
+	_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, plans)
+	// 0.9 没有被采样
+	_, err = orm.NewSelector[TestModel](db).Where(orm.C("Age").GT(1)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, plans)
+	// 0.2 被采样，全表扫描
+	_, err = orm.NewSelector[TestModel](db).Where(orm.C("Age").GT(1)).GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, "SELECT * FROM `test_model` WHERE `age` > ?;", plans[0].SQL)
+	assert.True(t, plans[0].FullScan())
+	assert.NoError(t, explainErr)
+}
+
+func TestQueryContext_Explain(t *testing.T) {
+	qc := &orm.QueryContext{Type: "SELECT"}
+	_, err := qc.Explain(context.Background())
+	assert.Equal(t, errors.New("orm: 语句还没有开始执行，无法 EXPLAIN"), err)
+}
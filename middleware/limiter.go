@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"orm"
+	"sync"
+	"time"
+)
+
+var ErrLimiterTimeout = errors.New("orm: 等待执行查询超时")
+
+type concurrencyLimiterBuilder struct {
+	limit        int
+	tableLimits  map[string]int
+	queueTimeout time.Duration
+	clock        Clock
+}
+
+// NewConcurrencyLimiterBuilder 限制每张表同时执行的查询数量，
+// 超过 limit 的查询排队等待，等待超过 QueueTimeout 返回 ErrLimiterTimeout。
+// limit 小于等于 0 表示不限制
+func NewConcurrencyLimiterBuilder(limit int) *concurrencyLimiterBuilder {
+	return &concurrencyLimiterBuilder{
+		limit:       limit,
+		tableLimits: map[string]int{},
+		clock:       realClock{},
+	}
+}
+
+// TableLimit 单独设置某张表的并发数，小于等于 0 表示不限制
+func (b *concurrencyLimiterBuilder) TableLimit(table string, limit int) *concurrencyLimiterBuilder {
+	b.tableLimits[table] = limit
+	return b
+}
+
+// QueueTimeout 排队的最长时间，0 表示一直等到 ctx 结束
+func (b *concurrencyLimiterBuilder) QueueTimeout(timeout time.Duration) *concurrencyLimiterBuilder {
+	b.queueTimeout = timeout
+	return b
+}
+
+// Clock 设置时钟，主要用于测试
+func (b *concurrencyLimiterBuilder) Clock(clock Clock) *concurrencyLimiterBuilder {
+	b.clock = clock
+	return b
+}
+
+func (b *concurrencyLimiterBuilder) Build() orm.Middleware {
+	var sems sync.Map
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			tbl := tableName(qc)
+			val, ok := sems.Load(tbl)
+			if !ok {
+				limit, ok := b.tableLimits[tbl]
+				if !ok {
+					limit = b.limit
+				}
+				var sem chan struct{}
+				if limit > 0 {
+					sem = make(chan struct{}, limit)
+				}
+				val, _ = sems.LoadOrStore(tbl, sem)
+			}
+			sem := val.(chan struct{})
+			if sem == nil {
+				return next(ctx, qc)
+			}
+			if err := b.acquire(ctx, sem); err != nil {
+				return &orm.QueryResult{Err: err}
+			}
+			defer func() {
+				<-sem
+			}()
+			return next(ctx, qc)
+		}
+	}
+}
+
+func (b *concurrencyLimiterBuilder) acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	default:
+	}
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := b.clock.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C()
+	}
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return ErrLimiterTimeout
+	}
+}
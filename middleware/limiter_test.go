@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+	"orm/model"
+)
+
+func TestConcurrencyLimiterBuilder_Build(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	m := NewConcurrencyLimiterBuilder(10).TableLimit("test_model", 1).QueueTimeout(time.Second).Clock(clock)
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	h := m.Build()(func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+		started <- struct{}{}
+		<-release
+		return &orm.QueryResult{}
+	})
+	qc := &orm.QueryContext{Type: "SELECT", Model: &model.Model{TableName: "test_model"}}
+	call := func(ctx context.Context) <-chan error {
+		res := make(chan error, 1)
+		go func() {
+			res <- h(ctx, qc).Err
+		}()
+		return res
+	}
+	// waitQueued 等到查询开始排队
+	waitQueued := func() {
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	first := call(context.Background())
+	<-started
+
+	// 第一个查询还没结束，排队超时
+	res := call(context.Background())
+	waitQueued()
+	clock.Advance(time.Second)
+	assert.Equal(t, ErrLimiterTimeout, <-res)
+
+	// ctx 先结束
+	ctx, cancel := context.WithCancel(context.Background())
+	res = call(ctx)
+	waitQueued()
+	cancel()
+	assert.Equal(t, context.Canceled, <-res)
+	assert.Equal(t, 0, clock.Timers())
+
+	// 第一个查询结束之后可以直接执行
+	close(release)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-call(context.Background()))
+}
+
+func TestConcurrencyLimiterBuilder_Unlimited(t *testing.T) {
+	m := NewConcurrencyLimiterBuilder(0).TableLimit("test_model", -1)
+	started := make(chan struct{}, 6)
+	release := make(chan struct{})
+	h := m.Build()(func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+		started <- struct{}{}
+		<-release
+		return &orm.QueryResult{}
+	})
+	res := make(chan error, 6)
+	for _, tbl := range []string{"test_model", "order"} {
+		qc := &orm.QueryContext{Type: "SELECT", Model: &model.Model{TableName: tbl}}
+		for i := 0; i < 3; i++ {
+			go func() {
+				res <- h(context.Background(), qc).Err
+			}()
+		}
+	}
+	// 不限制的时候所有查询都能同时执行
+	for i := 0; i < 6; i++ {
+		<-started
+	}
+	close(release)
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, <-res)
+	}
+}
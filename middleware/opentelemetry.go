@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
 	"go.opentelemetry.io/otel/trace"
 	"orm"
 )
@@ -12,26 +15,60 @@ import (
 const instrumentationName = "github.com/xjz9600/orm/middleware/tracing"
 
 type opentelemetry struct {
-	tracer trace.Tracer
+	tracer   trace.Tracer
+	system   attribute.KeyValue
+	omitArgs bool
+}
+
+// NewOpenTelemetryBuilder 按照 OpenTelemetry 数据库的语义约定记录 span
+func NewOpenTelemetryBuilder() *opentelemetry {
+	return &opentelemetry{
+		system: semconv.DBSystemOtherSQL,
+	}
+}
+
+// Tracer 默认使用全局的 TracerProvider
+func (m *opentelemetry) Tracer(tracer trace.Tracer) *opentelemetry {
+	m.tracer = tracer
+	return m
+}
+
+// DBSystem 设置 db.system，例如 mysql、sqlite
+func (m *opentelemetry) DBSystem(system string) *opentelemetry {
+	m.system = semconv.DBSystemKey.String(system)
+	return m
+}
+
+// OmitArgs 不在 span 里面记录查询参数，参数里面有敏感数据的时候使用
+func (m *opentelemetry) OmitArgs() *opentelemetry {
+	m.omitArgs = true
+	return m
 }
 
 func (m opentelemetry) Build() orm.Middleware {
 	if m.tracer == nil {
 		m.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
 	}
+	if !m.system.Valid() {
+		m.system = semconv.DBSystemOtherSQL
+	}
 	return func(next orm.Handler) orm.Handler {
 		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
-			tbl := qc.Model.TableName
-			spanCtx, span := m.tracer.Start(ctx, fmt.Sprintf("%s-%s", qc.Type, tbl))
+			tbl := tableName(qc)
+			spanCtx, span := m.tracer.Start(ctx, fmt.Sprintf("%s %s", qc.Type, tbl),
+				trace.WithSpanKind(trace.SpanKindClient))
 			defer span.End()
-			q, _ := qc.Builder.Build()
-			if q != nil {
-				span.SetAttributes(attribute.String("sql", q.SQL))
+			span.SetAttributes(m.system, semconv.DBOperation(qc.Type), semconv.DBSQLTable(tbl))
+			if q, err := qc.Query(); err == nil {
+				span.SetAttributes(semconv.DBStatement(q.SQL))
+				if !m.omitArgs && len(q.Args) > 0 {
+					span.SetAttributes(attribute.String("db.statement.args", fmt.Sprint(q.Args)))
+				}
 			}
-			span.SetAttributes(attribute.String("component", "orm"))
 			res := next(spanCtx, qc)
-			if res.Err != nil {
-				span.RecordError(res.Err)
+			if err := resultErr(res); err != nil && !errors.Is(err, orm.ErrNoRows) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
 			return res
 		}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"orm"
+)
+
+// mockTracer 记录下所有 span 的名字和属性
+type mockTracer struct {
+	spans []*mockSpan
+}
+
+func (m *mockTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &mockSpan{
+		Span:  trace.SpanFromContext(ctx),
+		name:  name,
+		attrs: map[attribute.Key]string{},
+	}
+	m.spans = append(m.spans, span)
+	return ctx, span
+}
+
+type mockSpan struct {
+	trace.Span
+	name  string
+	attrs map[attribute.Key]string
+}
+
+func (m *mockSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		m.attrs[a.Key] = a.Value.Emit()
+	}
+}
+
+func TestOpenTelemetry_Build(t *testing.T) {
+	testCases := []struct {
+		name      string
+		m         *opentelemetry
+		wantAttrs map[attribute.Key]string
+	}{
+		{
+			name: "with args",
+			m:    NewOpenTelemetryBuilder().DBSystem("mysql"),
+			wantAttrs: map[attribute.Key]string{
+				"db.system":         "mysql",
+				"db.operation":      "SELECT",
+				"db.sql.table":      "test_model",
+				"db.statement":      "SELECT * FROM `test_model` WHERE `id` = ?;",
+				"db.statement.args": "[1]",
+			},
+		},
+		{
+			name: "omit args",
+			m:    NewOpenTelemetryBuilder().OmitArgs(),
+			wantAttrs: map[attribute.Key]string{
+				"db.system":    "other_sql",
+				"db.operation": "SELECT",
+				"db.sql.table": "test_model",
+				"db.statement": "SELECT * FROM `test_model` WHERE `id` = ?;",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			tracer := &mockTracer{}
+			db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(tc.m.Tracer(tracer).Build()))
+			require.NoError(t, err)
+			mock.ExpectQuery("SELECT .*").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+			_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(context.Background())
+			require.NoError(t, err)
+			require.Len(t, tracer.spans, 1)
+			assert.Equal(t, "SELECT test_model", tracer.spans[0].name)
+			assert.Equal(t, tc.wantAttrs, tracer.spans[0].attrs)
+			// 查询只构造一次，参数不会重复
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
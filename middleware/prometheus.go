@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"orm"
 	"time"
@@ -12,35 +13,88 @@ type prometheusBuilder struct {
 	subSystem string
 	nameSpace string
 	help      string
+	registry  prometheus.Registerer
+	buckets   []float64
 }
 
+// NewPrometheusBuilder 会注册三个指标：
+// name 是按照 type、table、status 区分的执行时间直方图，单位是秒；
+// name_errors_total 是错误次数；name_in_flight 是正在执行的查询数量
 func NewPrometheusBuilder(name, subSystem, nameSpace, help string) *prometheusBuilder {
-	return &prometheusBuilder{name, subSystem, nameSpace, help}
+	return &prometheusBuilder{
+		name:      name,
+		subSystem: subSystem,
+		nameSpace: nameSpace,
+		help:      help,
+		registry:  prometheus.DefaultRegisterer,
+		buckets:   prometheus.DefBuckets,
+	}
+}
+
+// Registerer 设置注册指标的地方，默认是 prometheus.DefaultRegisterer
+func (p *prometheusBuilder) Registerer(registry prometheus.Registerer) *prometheusBuilder {
+	p.registry = registry
+	return p
 }
 
+// Buckets 设置直方图的桶，单位是秒
+func (p *prometheusBuilder) Buckets(buckets []float64) *prometheusBuilder {
+	p.buckets = buckets
+	return p
+}
+
+// Build 可以重复调用，已经注册过的指标会被复用
 func (p *prometheusBuilder) Build() orm.Middleware {
-	vector := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+	duration := register(p.registry, prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:      p.name,
 		Subsystem: p.subSystem,
 		Namespace: p.nameSpace,
 		Help:      p.help,
-		Objectives: map[float64]float64{
-			0.5:   0.01,
-			0.75:  0.01,
-			0.90:  0.01,
-			0.99:  0.001,
-			0.999: 0.0001,
-		},
-	}, []string{"type", "table"})
-	prometheus.MustRegister(vector)
+		Buckets:   p.buckets,
+	}, []string{"type", "table", "status"}))
+	errCnt := register(p.registry, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      p.name + "_errors_total",
+		Subsystem: p.subSystem,
+		Namespace: p.nameSpace,
+		Help:      "执行出错的查询数量",
+	}, []string{"type", "table"}))
+	inFlight := register(p.registry, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      p.name + "_in_flight",
+		Subsystem: p.subSystem,
+		Namespace: p.nameSpace,
+		Help:      "正在执行的查询数量",
+	}, []string{"type", "table"}))
 	return func(next orm.Handler) orm.Handler {
 		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			tbl := tableName(qc)
+			gauge := inFlight.WithLabelValues(qc.Type, tbl)
+			gauge.Inc()
+			// next 可能 panic，用 defer 保证计数能减回去
+			defer gauge.Dec()
 			startTime := time.Now()
-			defer func() {
-				duration := time.Now().Sub(startTime).Milliseconds()
-				vector.WithLabelValues(qc.Type, qc.Model.TableName).Observe(float64(duration))
-			}()
-			return next(ctx, qc)
+			res := next(ctx, qc)
+			status := "ok"
+			if err := resultErr(res); err != nil && !errors.Is(err, orm.ErrNoRows) {
+				status = "error"
+				errCnt.WithLabelValues(qc.Type, tbl).Inc()
+			}
+			duration.WithLabelValues(qc.Type, tbl, status).Observe(time.Since(startTime).Seconds())
+			return res
+		}
+	}
+}
+
+// register 注册 c，如果已经注册过同样的指标就返回之前注册的那个
+func register[C prometheus.Collector](registry prometheus.Registerer, c C) C {
+	err := registry.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing
 		}
 	}
+	panic(err)
 }
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestPrometheusBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	registry := prometheus.NewRegistry()
+	b := NewPrometheusBuilder("query", "orm", "test", "查询耗时").Registerer(registry)
+	// 重复 Build 不会 panic
+	_ = b.Build()
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(b.Build()))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT .*").WillReturnError(errors.New("query error"))
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	ctx := context.Background()
+	_, err = orm.NewSelector[TestModel](db).Get(ctx)
+	require.NoError(t, err)
+	_, err = orm.NewSelector[TestModel](db).Get(ctx)
+	require.Error(t, err)
+	require.NoError(t, orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(1)).Exec(ctx).Err())
+
+	mfs, err := registry.Gather()
+	require.NoError(t, err)
+	counts := map[string]float64{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName()
+			for _, l := range m.GetLabel() {
+				key += "/" + l.GetValue()
+			}
+			switch {
+			case m.GetHistogram() != nil:
+				counts[key] = float64(m.GetHistogram().GetSampleCount())
+			case m.GetCounter() != nil:
+				counts[key] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				counts[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"test_orm_query/ok/test_model/SELECT":           1,
+		"test_orm_query/error/test_model/SELECT":        1,
+		"test_orm_query/ok/test_model/DELETE":           1,
+		"test_orm_query_errors_total/test_model/SELECT": 1,
+		"test_orm_query_in_flight/test_model/SELECT":    0,
+		"test_orm_query_in_flight/test_model/DELETE":    0,
+	}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
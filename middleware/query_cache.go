@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"orm"
+	"orm/cache"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type queryCacheBuilder struct {
+	cache  cache.Cache
+	prefix string
+}
+
+// NewQueryCacheBuilder 缓存 SELECT 的结果。
+// 只有通过 Selector.Cache 或者 orm.WithQueryCache 标记过的查询才会被缓存，
+// INSERT、UPDATE、DELETE 会让语句用到的表上的缓存全部失效，包括 JOIN 和子查询里面的表。
+// 结果用 gob 序列化，和 json 标签无关，没有导出的字段不会被缓存
+func NewQueryCacheBuilder(c cache.Cache) *queryCacheBuilder {
+	return &queryCacheBuilder{
+		cache:  c,
+		prefix: "orm",
+	}
+}
+
+// Prefix 设置缓存键的前缀，默认是 orm
+func (b *queryCacheBuilder) Prefix(prefix string) *queryCacheBuilder {
+	b.prefix = prefix
+	return b
+}
+
+func (b *queryCacheBuilder) Build() orm.Middleware {
+	g := &singleflight.Group{}
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			switch qc.Type {
+			case "SELECT":
+				ttl, ok := orm.QueryCacheTTL(ctx)
+				if !ok || qc.ResultType == nil {
+					return next(ctx, qc)
+				}
+				return b.get(ctx, qc, ttl, g, next)
+			case "INSERT", "UPDATE", "DELETE":
+				res := next(ctx, qc)
+				if r, ok := res.Result.(orm.Result); ok && r.Err() == nil {
+					// 失效失败的话旧的缓存最多存活到过期，不影响这次写入的结果
+					for _, tbl := range b.tables(ctx, qc) {
+						_ = b.invalidate(ctx, tbl)
+					}
+				}
+				return res
+			default:
+				return next(ctx, qc)
+			}
+		}
+	}
+}
+
+func (b *queryCacheBuilder) get(ctx context.Context, qc *orm.QueryContext, ttl time.Duration,
+	g *singleflight.Group, next orm.Handler) *orm.QueryResult {
+	key, err := b.key(ctx, qc)
+	if err != nil {
+		return &orm.QueryResult{Err: err}
+	}
+	if data, err := b.cache.Get(ctx, key); err == nil {
+		if res, err := b.decode(qc, data); err == nil {
+			return &orm.QueryResult{Result: res}
+		}
+	}
+	type loaded struct {
+		res  *orm.QueryResult
+		data []byte
+	}
+	val, err, shared := g.Do(key, func() (any, error) {
+		res := next(ctx, qc)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(res.Result); err != nil {
+			// 序列化不了的结果不缓存，等待的调用各自查询
+			return loaded{res: res}, nil
+		}
+		data := buf.Bytes()
+		// 缓存写失败不影响查询结果
+		_ = b.cache.Set(ctx, key, data, ttl)
+		return loaded{res: res, data: data}, nil
+	})
+	if err != nil {
+		return &orm.QueryResult{Err: err}
+	}
+	l := val.(loaded)
+	if !shared {
+		return l.res
+	}
+	if l.data == nil {
+		return next(ctx, qc)
+	}
+	// 其它等待的调用各自反序列化一份，避免共享同一个结果
+	res, err := b.decode(qc, l.data)
+	return &orm.QueryResult{Result: res, Err: err}
+}
+
+func (b *queryCacheBuilder) decode(qc *orm.QueryContext, data any) (any, error) {
+	var bs []byte
+	switch d := data.(type) {
+	case []byte:
+		bs = d
+	case string:
+		bs = []byte(d)
+	default:
+		return nil, fmt.Errorf("orm: 不支持的缓存数据类型 %T", data)
+	}
+	res := reflect.New(qc.ResultType).Interface()
+	err := gob.NewDecoder(bytes.NewReader(bs)).Decode(res)
+	return res, err
+}
+
+// key 由语句用到的所有表的版本号和 SQL、参数组成，任何一张表的版本号变化之后旧的键就不会再被访问
+func (b *queryCacheBuilder) key(ctx context.Context, qc *orm.QueryContext) (string, error) {
+	q, err := qc.Query()
+	if err != nil {
+		return "", err
+	}
+	tbls := b.tables(ctx, qc)
+	versions := make([]string, 0, len(tbls))
+	for _, tbl := range tbls {
+		versions = append(versions, b.version(ctx, tbl))
+	}
+	h := sha1.New()
+	h.Write([]byte(q.SQL))
+	h.Write([]byte(fmt.Sprintf("%#v", q.Args)))
+	h.Write([]byte(qc.ResultType.String()))
+	return fmt.Sprintf("%s:query:%s:%s:%s", b.prefix, strings.Join(tbls, ","),
+		strings.Join(versions, ","), hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// tables 返回影子表替换之后语句用到的表，拿不到 Statement 的时候退回到模型的表。
+// 影子库的流量没有替换表名，单独加上前缀，避免和线上的表共用版本号
+func (b *queryCacheBuilder) tables(ctx context.Context, qc *orm.QueryContext) []string {
+	tbls, err := qc.Tables()
+	if err != nil || len(tbls) == 0 {
+		tbls = []string{tableName(qc)}
+	}
+	if orm.IsShadow(ctx) {
+		for i, tbl := range tbls {
+			tbls[i] = "shadow:" + tbl
+		}
+	}
+	return tbls
+}
+
+func (b *queryCacheBuilder) version(ctx context.Context, tbl string) string {
+	val, err := b.cache.Get(ctx, b.versionKey(tbl))
+	if err != nil {
+		return "0"
+	}
+	switch v := val.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (b *queryCacheBuilder) invalidate(ctx context.Context, tbl string) error {
+	return b.cache.Set(ctx, b.versionKey(tbl), strconv.FormatInt(time.Now().UnixNano(), 10), 0)
+}
+
+func (b *queryCacheBuilder) versionKey(tbl string) string {
+	return fmt.Sprintf("%s:version:%s", b.prefix, tbl)
+}
+
+func tableName(qc *orm.QueryContext) string {
+	if qc.Model == nil {
+		return ""
+	}
+	return qc.Model.TableName
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+	"orm/cache"
+)
+
+func TestQueryCacheBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	c := cache.NewBuildInMapCache(time.Minute)
+	defer c.Close()
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(NewQueryCacheBuilder(c).Build()))
+	require.NoError(t, err)
+
+	mockRows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tom", 18, "Jerry")
+	mock.ExpectQuery("SELECT .*").WithArgs(1).WillReturnRows(mockRows)
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	mockRows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tim", 19, "Jerry")
+	mock.ExpectQuery("SELECT .*").WithArgs(1).WillReturnRows(mockRows)
+	mockRows = sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tim", 19, "Jerry")
+	mock.ExpectQuery("SELECT .*").WithArgs(1).WillReturnRows(mockRows)
+
+	ctx := context.Background()
+	want := &TestModel{Id: 1, FirstName: "Tom", Age: 18, LastName: sql.NullString{String: "Jerry", Valid: true}}
+	for i := 0; i < 2; i++ {
+		res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Cache(time.Minute).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, res)
+	}
+
+	// 删除会让缓存失效
+	require.NoError(t, orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(2)).Exec(ctx).Err())
+	want = &TestModel{Id: 1, FirstName: "Tim", Age: 19, LastName: sql.NullString{String: "Jerry", Valid: true}}
+	res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(orm.WithQueryCache(ctx, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, want, res)
+
+	// 没有标记的查询不走缓存
+	res, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryCacheBuilder_Singleflight(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	c := cache.NewBuildInMapCache(time.Minute)
+	defer c.Close()
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(NewQueryCacheBuilder(c).Build()))
+	require.NoError(t, err)
+
+	mockRows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tom", 18, "Jerry")
+	mock.ExpectQuery("SELECT .*").WithArgs(1).WillDelayFor(100 * time.Millisecond).WillReturnRows(mockRows)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).
+				Cache(time.Minute).Get(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "Tom", res.FirstName)
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type cacheUser struct {
+	Id        int64
+	Name      string `json:"-"`
+	CreatedAt time.Time
+}
+
+type cacheOrder struct {
+	Id     int64
+	UserId int64
+}
+
+func TestQueryCacheBuilder_Gob(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	c := cache.NewBuildInMapCache(time.Minute)
+	defer c.Close()
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(NewQueryCacheBuilder(c).Build()))
+	require.NoError(t, err)
+
+	now := time.Date(2022, 1, 2, 3, 4, 5, 6, time.UTC)
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).
+		AddRow(1, "Tom", now).AddRow(2, "Jerry", now))
+
+	// json 会丢掉 json:"-" 的字段
+	want := []*cacheUser{{Id: 1, Name: "Tom", CreatedAt: now}, {Id: 2, Name: "Jerry", CreatedAt: now}}
+	for i := 0; i < 2; i++ {
+		res, err := orm.NewSelector[cacheUser](db).Cache(time.Minute).GetMulti(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, want, res)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryCacheBuilder_Invalidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []orm.DBOptions
+		query    func(db *orm.DB) *orm.Selector[TestModel]
+		writeCtx context.Context
+		// 写入之后要不要重新查询
+		wantRequery bool
+	}{
+		{
+			name: "join",
+			query: func(db *orm.DB) *orm.Selector[TestModel] {
+				t1, t2 := orm.TableOf(&TestModel{}), orm.TableOf(&cacheOrder{})
+				return orm.NewSelector[TestModel](db).Select(t1.C("Id")).
+					From(t1.Join(t2).On(t1.C("Id").EQ(t2.C("UserId"))))
+			},
+			writeCtx:    context.Background(),
+			wantRequery: true,
+		},
+		{
+			name: "subquery",
+			query: func(db *orm.DB) *orm.Selector[TestModel] {
+				sub := orm.NewSelector[cacheOrder](db).Select(orm.C("UserId")).AsSubQuery()
+				return orm.NewSelector[TestModel](db).Where(orm.C("Id").InQuery(sub))
+			},
+			writeCtx:    context.Background(),
+			wantRequery: true,
+		},
+		{
+			// 写影子表不会让线上的缓存失效
+			name: "shadow write",
+			opts: []orm.DBOptions{orm.DBWithShadowTable()},
+			query: func(db *orm.DB) *orm.Selector[TestModel] {
+				sub := orm.NewSelector[cacheOrder](db).Select(orm.C("UserId")).AsSubQuery()
+				return orm.NewSelector[TestModel](db).Where(orm.C("Id").InQuery(sub))
+			},
+			writeCtx: orm.WithShadow(context.Background()),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			c := cache.NewBuildInMapCache(time.Minute)
+			defer c.Close()
+			opts := append([]orm.DBOptions{orm.DBWithMiddleware(NewQueryCacheBuilder(c).Build())}, tc.opts...)
+			db, err := orm.OpenDB(mockDB, opts...)
+			require.NoError(t, err)
+
+			mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			if tc.wantRequery {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+			}
+
+			ctx := context.Background()
+			_, err = tc.query(db).Cache(time.Minute).Get(ctx)
+			require.NoError(t, err)
+			require.NoError(t, orm.NewDeleter[cacheOrder](db).Where(orm.C("Id").EQ(1)).Exec(tc.writeCtx).Err())
+			res, err := tc.query(db).Cache(time.Minute).Get(ctx)
+			require.NoError(t, err)
+			if tc.wantRequery {
+				assert.Equal(t, int64(2), res.Id)
+			} else {
+				assert.Equal(t, int64(1), res.Id)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
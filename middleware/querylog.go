@@ -26,7 +26,7 @@ func (m *queryLog) LogFunc(fn func(query string, args []any)) *queryLog {
 func (m queryLog) Build() orm.Middleware {
 	return func(next orm.Handler) orm.Handler {
 		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
-			q, err := qc.Builder.Build()
+			q, err := qc.Query()
 			if err != nil {
 				return &orm.QueryResult{
 					Err: err,
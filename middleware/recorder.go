@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"orm"
+	"sync"
+)
+
+// QueryRecorder 按照顺序记录经过 DB 的语句，一般用在测试里面，
+// 配合 ormtest.AssertGolden 把生成的 SQL 和 golden 文件比较
+type QueryRecorder struct {
+	mu      sync.Mutex
+	queries []orm.Query
+}
+
+func NewQueryRecorder() *QueryRecorder {
+	return &QueryRecorder{}
+}
+
+func (r *QueryRecorder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			q, err := qc.Query()
+			if err != nil {
+				return &orm.QueryResult{
+					Err: err,
+				}
+			}
+			// 执行失败的语句也要记录
+			r.mu.Lock()
+			r.queries = append(r.queries, *q)
+			r.mu.Unlock()
+			return next(ctx, qc)
+		}
+	}
+}
+
+// Queries 返回记录的语句的副本
+func (r *QueryRecorder) Queries() []orm.Query {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]orm.Query, len(r.queries))
+	copy(res, r.queries)
+	return res
+}
+
+// Reset 清空记录
+func (r *QueryRecorder) Reset() {
+	r.mu.Lock()
+	r.queries = nil
+	r.mu.Unlock()
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestQueryRecorder(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	r := NewQueryRecorder()
+	db, err := orm.OpenDB(mockDB, orm.DBWithDialect(orm.DialectPostgreSQL), orm.DBWithMiddleware(r.Build()))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE .*").WillReturnError(errors.New("mock error"))
+	_, err = orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+	err = orm.NewDeleter[TestModel](db).Where(orm.C("Age").GT(18)).Exec(context.Background()).Err()
+	assert.Error(t, err)
+	// 构造失败的语句没有 SQL，不记录
+	err = orm.NewUpdater[TestModel](db).Exec(context.Background()).Err()
+	assert.Error(t, err)
+
+	assert.Equal(t, []orm.Query{
+		{SQL: `SELECT * FROM "test_model" WHERE "id" = ?;`, Args: []any{1}},
+		{SQL: `DELETE FROM "test_model" WHERE "age" > ?;`, Args: []any{18}},
+	}, r.Queries())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	r.Reset()
+	assert.Empty(t, r.Queries())
+}
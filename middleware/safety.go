@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"orm"
+)
+
+var (
+	ErrMissingWhere = errors.New("orm: 禁止执行没有 WHERE 条件的 DELETE 或者 UPDATE")
+	ErrMissingLimit = errors.New("orm: 禁止执行没有 LIMIT 的 SELECT")
+)
+
+type safetyGuardBuilder struct {
+	// whereTables 为空的时候所有表都要求 DELETE 和 UPDATE 带 WHERE
+	whereTables map[string]struct{}
+	limitTables map[string]struct{}
+}
+
+// NewSafetyGuardBuilder 默认拒绝所有没有 WHERE 的 DELETE 和 UPDATE，
+// 可以通过 WhereTables 缩小范围，通过 LimitTables 要求查询必须带 LIMIT
+func NewSafetyGuardBuilder() *safetyGuardBuilder {
+	return &safetyGuardBuilder{}
+}
+
+// WhereTables 只对这些表要求 DELETE 和 UPDATE 带 WHERE
+func (b *safetyGuardBuilder) WhereTables(tables ...string) *safetyGuardBuilder {
+	b.whereTables = toSet(tables)
+	return b
+}
+
+// LimitTables 要求这些表上的 SELECT 必须带 LIMIT
+func (b *safetyGuardBuilder) LimitTables(tables ...string) *safetyGuardBuilder {
+	b.limitTables = toSet(tables)
+	return b
+}
+
+func (b *safetyGuardBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			if err := b.check(qc); err != nil {
+				return &orm.QueryResult{Err: err}
+			}
+			return next(ctx, qc)
+		}
+	}
+}
+
+func (b *safetyGuardBuilder) check(qc *orm.QueryContext) error {
+	tbl := tableName(qc)
+	switch qc.Type {
+	case "DELETE", "UPDATE":
+		if len(b.whereTables) > 0 {
+			if _, ok := b.whereTables[tbl]; !ok {
+				return nil
+			}
+		}
+		stmt, err := qc.Statement()
+		if err != nil {
+			return err
+		}
+		// 只看调用方自己的条件，Scope 和租户的条件不算
+		if len(stmt.Where) == 0 {
+			return ErrMissingWhere
+		}
+	case "SELECT":
+		if _, ok := b.limitTables[tbl]; !ok {
+			return nil
+		}
+		stmt, err := qc.Statement()
+		if err != nil {
+			return err
+		}
+		if stmt.Limit <= 0 {
+			return ErrMissingLimit
+		}
+	}
+	return nil
+}
+
+func toSet(vals []string) map[string]struct{} {
+	res := make(map[string]struct{}, len(vals))
+	for _, v := range vals {
+		res[v] = struct{}{}
+	}
+	return res
+}
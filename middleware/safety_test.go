@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestSafetyGuardBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name    string
+		m       *safetyGuardBuilder
+		mock    func(mock sqlmock.Sqlmock)
+		exec    func(db *orm.DB) error
+		wantErr error
+	}{
+		{
+			name: "delete without where",
+			m:    NewSafetyGuardBuilder(),
+			exec: func(db *orm.DB) error {
+				return orm.NewDeleter[TestModel](db).Exec(context.Background()).Err()
+			},
+			wantErr: ErrMissingWhere,
+		},
+		{
+			name: "delete with where",
+			m:    NewSafetyGuardBuilder(),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *orm.DB) error {
+				return orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(1)).Exec(context.Background()).Err()
+			},
+		},
+		{
+			name: "delete other table",
+			m:    NewSafetyGuardBuilder().WhereTables("order"),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			exec: func(db *orm.DB) error {
+				return orm.NewDeleter[TestModel](db).Exec(context.Background()).Err()
+			},
+		},
+		{
+			name: "select without limit",
+			m:    NewSafetyGuardBuilder().LimitTables("test_model"),
+			exec: func(db *orm.DB) error {
+				_, err := orm.NewSelector[TestModel](db).Where(orm.C("Id").EQ(1)).Get(context.Background())
+				return err
+			},
+			wantErr: ErrMissingLimit,
+		},
+		{
+			name: "select with limit",
+			m:    NewSafetyGuardBuilder().LimitTables("test_model"),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			exec: func(db *orm.DB) error {
+				_, err := orm.NewSelector[TestModel](db).Limit(10).Offset(20).Get(context.Background())
+				return err
+			},
+		},
+		{
+			name: "select not configured",
+			m:    NewSafetyGuardBuilder(),
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			exec: func(db *orm.DB) error {
+				_, err := orm.NewSelector[TestModel](db).Get(context.Background())
+				return err
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			if tc.mock != nil {
+				tc.mock(mock)
+			}
+			db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(tc.m.Build()))
+			require.NoError(t, err)
+			err = tc.exec(db)
+			assert.Equal(t, tc.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSafetyGuardBuilder_Tenant(t *testing.T) {
+	type Post struct {
+		Id       int64
+		TenantId int64
+		Title    string
+	}
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(NewSafetyGuardBuilder().Build()),
+		orm.DBWithTenant("TenantId", func(ctx context.Context) (any, bool) {
+			return int64(7), true
+		}),
+		orm.DBWithScope(func(qc *orm.QueryContext) []orm.Predicate {
+			return []orm.Predicate{orm.C("Id").GT(0)}
+		}))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 租户和 Scope 的条件不算，仍然会删除整个租户的数据
+	err = orm.NewDeleter[Post](db).Exec(ctx).Err()
+	assert.Equal(t, ErrMissingWhere, err)
+	err = orm.NewUpdater[Post](db).Set(orm.Assign("Title", "a")).Exec(ctx).Err()
+	assert.Equal(t, ErrMissingWhere, err)
+
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	err = orm.NewDeleter[Post](db).Where(orm.C("Id").EQ(1)).Exec(ctx).Err()
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"orm"
+	"time"
+)
+
+// SlowQuery 慢查询日志里面的字段
+type SlowQuery struct {
+	Type     string
+	Table    string
+	SQL      string
+	Args     []any
+	Duration time.Duration
+	// RowsAffected 只有 INSERT、UPDATE、DELETE 这类语句才有，查询的时候是 -1
+	RowsAffected int64
+	Err          error
+}
+
+type slowQueryBuilder struct {
+	threshold time.Duration
+	logFunc   func(ctx context.Context, q SlowQuery)
+}
+
+// NewSlowQueryBuilder 记录执行时间不小于 threshold 的查询
+func NewSlowQueryBuilder(threshold time.Duration) *slowQueryBuilder {
+	return &slowQueryBuilder{
+		threshold: threshold,
+		logFunc: func(ctx context.Context, q SlowQuery) {
+			log.Printf("slow query: type=%s table=%s duration=%s rows_affected=%d sql=%s args=%v err=%v",
+				q.Type, q.Table, q.Duration, q.RowsAffected, q.SQL, q.Args, q.Err)
+		},
+	}
+}
+
+func (b *slowQueryBuilder) LogFunc(fn func(ctx context.Context, q SlowQuery)) *slowQueryBuilder {
+	b.logFunc = fn
+	return b
+}
+
+func (b *slowQueryBuilder) Build() orm.Middleware {
+	return func(next orm.Handler) orm.Handler {
+		return func(ctx context.Context, qc *orm.QueryContext) *orm.QueryResult {
+			startTime := time.Now()
+			res := next(ctx, qc)
+			duration := time.Since(startTime)
+			if duration < b.threshold {
+				return res
+			}
+			sq := SlowQuery{
+				Type:         qc.Type,
+				Table:        tableName(qc),
+				Duration:     duration,
+				RowsAffected: rowsAffected(res),
+				Err:          resultErr(res),
+			}
+			if q, err := qc.Query(); err == nil {
+				sq.SQL, sq.Args = q.SQL, q.Args
+			}
+			b.logFunc(ctx, sq)
+			return res
+		}
+	}
+}
+
+// rowsAffected 返回 INSERT、UPDATE、DELETE 的影响行数，其它查询返回 -1
+func rowsAffected(res *orm.QueryResult) int64 {
+	r, ok := res.Result.(orm.Result)
+	if !ok {
+		return -1
+	}
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return affected
+}
+
+// resultErr 执行语句的错误放在 orm.Result 里面，这里统一取出来
+func resultErr(res *orm.QueryResult) error {
+	if res.Err != nil {
+		return res.Err
+	}
+	if r, ok := res.Result.(orm.Result); ok {
+		return r.Err()
+	}
+	return nil
+}
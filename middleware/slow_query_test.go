@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestSlowQueryBuilder_Build(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	var logs []SlowQuery
+	m := NewSlowQueryBuilder(50 * time.Millisecond).LogFunc(func(ctx context.Context, q SlowQuery) {
+		logs = append(logs, q)
+	})
+	db, err := orm.OpenDB(mockDB, orm.DBWithMiddleware(m.Build()))
+	require.NoError(t, err)
+
+	mock.ExpectExec("DELETE .*").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE .*").WillDelayFor(100 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT .*").WillDelayFor(100 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	ctx := context.Background()
+	require.NoError(t, orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(1)).Exec(ctx).Err())
+	require.NoError(t, orm.NewDeleter[TestModel](db).Where(orm.C("Id").EQ(2)).Exec(ctx).Err())
+	_, err = orm.NewSelector[TestModel](db).Get(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, "DELETE", logs[0].Type)
+	assert.Equal(t, "test_model", logs[0].Table)
+	assert.Equal(t, "DELETE FROM `test_model` WHERE `id` = ?;", logs[0].SQL)
+	assert.Equal(t, []any{2}, logs[0].Args)
+	assert.Equal(t, int64(2), logs[0].RowsAffected)
+	assert.GreaterOrEqual(t, logs[0].Duration, 50*time.Millisecond)
+	assert.Equal(t, "SELECT", logs[1].Type)
+	assert.Equal(t, int64(-1), logs[1].RowsAffected)
+}
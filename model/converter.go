@@ -0,0 +1,281 @@
+package model
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"orm/internal/errs"
+	"reflect"
+	"sync"
+)
+
+const tagKeySerializer = "serializer"
+
+// Converter 负责字段的值和数据库里面的值之间的转换
+type Converter interface {
+	// ToDB 把字段的值转换成写入数据库的值
+	ToDB(val any) (driver.Value, error)
+	// FromDB 把数据库读出来的 src 转换之后写入 dst，dst 是字段的指针
+	FromDB(src any, dst any) error
+}
+
+// DeterministicConverter 是同一个值每次转换的结果都一样的 Converter，
+// 只有这种 Converter 的字段才能用在查询条件里面，比如枚举。
+// 加密和序列化的字段用在查询条件里面会返回错误
+type DeterministicConverter interface {
+	Converter
+	Deterministic()
+}
+
+// KeyProvider 返回 AES 的密钥，长度必须是 16、24 或者 32
+type KeyProvider func() ([]byte, error)
+
+var (
+	typeConverters sync.Map
+	serializers    sync.Map
+	keyProvider    KeyProvider
+	keyMu          sync.RWMutex
+)
+
+func init() {
+	RegisterSerializer("json", jsonConverter{})
+	RegisterSerializer("gob", gobConverter{})
+	RegisterSerializer("aes", NewAESConverter(func() ([]byte, error) {
+		keyMu.RLock()
+		p := keyProvider
+		keyMu.RUnlock()
+		if p == nil {
+			return nil, errs.ErrMissingKeyProvider
+		}
+		return p()
+	}))
+}
+
+// RegisterConverter 让所有类型是 T 的字段都使用 c，
+// 需要在模型第一次被解析之前注册
+func RegisterConverter[T any](c Converter) {
+	typeConverters.Store(reflect.TypeOf(new(T)).Elem(), c)
+}
+
+// RegisterSerializer 注册可以在标签 orm:"serializer=name" 里面使用的 Converter，
+// 内置了 json、gob 和 aes
+func RegisterSerializer(name string, c Converter) {
+	serializers.Store(name, c)
+}
+
+// SetKeyProvider 设置 serializer=aes 使用的密钥
+func SetKeyProvider(p KeyProvider) {
+	keyMu.Lock()
+	keyProvider = p
+	keyMu.Unlock()
+}
+
+// converterOf 标签优先于注册的类型，最后才是 Enum
+func converterOf(typ reflect.Type, tags map[string]string) (Converter, error) {
+	if serializer := tags[tagKeySerializer]; serializer != "" {
+		c, ok := serializers.Load(serializer)
+		if !ok {
+			return nil, errs.NewErrUnknownSerializer(serializer)
+		}
+		return c.(Converter), nil
+	}
+	if c, ok := typeConverters.Load(typ); ok {
+		return c.(Converter), nil
+	}
+	if storage, ok := tags[tagKeyEnum]; ok || typ.Implements(enumType) {
+		return newEnumConverter(typ, storage)
+	}
+	return nil, nil
+}
+
+// Value 返回写入数据库的值，没有 Converter 的时候就是 val 本身
+func (f *Field) Value(val any) (any, error) {
+	if f.Converter == nil {
+		return val, nil
+	}
+	return f.Converter.ToDB(val)
+}
+
+// Scanner 返回传给 rows.Scan 的参数，dst 是字段的指针
+func (f *Field) Scanner(dst any) any {
+	if f.Converter == nil {
+		return dst
+	}
+	return &convertScanner{c: f.Converter, dst: dst}
+}
+
+type convertScanner struct {
+	c   Converter
+	dst any
+}
+
+func (s *convertScanner) Scan(src any) error {
+	return s.c.FromDB(src, s.dst)
+}
+
+// bytesOf NULL 返回 nil
+func bytesOf(src any) ([]byte, error) {
+	switch data := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return data, nil
+	case string:
+		return []byte(data), nil
+	default:
+		return nil, errs.NewErrUnsupportedSource(src)
+	}
+}
+
+// isNil nil 的 map、slice、指针写成 NULL
+func isNil(val any) bool {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// setZero 数据库里面是 NULL 的时候把字段设置为零值
+func setZero(dst any) {
+	v := reflect.ValueOf(dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
+
+type jsonConverter struct{}
+
+func (jsonConverter) ToDB(val any) (driver.Value, error) {
+	if isNil(val) {
+		return nil, nil
+	}
+	return json.Marshal(val)
+}
+
+func (jsonConverter) FromDB(src any, dst any) error {
+	bs, err := bytesOf(src)
+	if err != nil {
+		return err
+	}
+	// 先清空，避免 map 里面残留旧的键
+	setZero(dst)
+	if bs == nil {
+		return nil
+	}
+	return json.Unmarshal(bs, dst)
+}
+
+type gobConverter struct{}
+
+func (gobConverter) ToDB(val any) (driver.Value, error) {
+	if isNil(val) {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobConverter) FromDB(src any, dst any) error {
+	bs, err := bytesOf(src)
+	if err != nil {
+		return err
+	}
+	setZero(dst)
+	if bs == nil {
+		return nil
+	}
+	return gob.NewDecoder(bytes.NewReader(bs)).Decode(dst)
+}
+
+type aesConverter struct {
+	key KeyProvider
+}
+
+// NewAESConverter 使用 AES-GCM 加密 string 或者 []byte 字段，
+// 数据库里面存的是 base64 编码之后的 nonce 加密文
+func NewAESConverter(key KeyProvider) Converter {
+	return aesConverter{key: key}
+}
+
+func (a aesConverter) aead() (cipher.AEAD, error) {
+	key, err := a.key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (a aesConverter) ToDB(val any) (driver.Value, error) {
+	var plain []byte
+	switch v := val.(type) {
+	case string:
+		plain = []byte(v)
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		plain = v
+	default:
+		return nil, errs.NewErrUnsupportedAESType(val)
+	}
+	gcm, err := a.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func (a aesConverter) FromDB(src any, dst any) error {
+	bs, err := bytesOf(src)
+	if err != nil {
+		return err
+	}
+	setZero(dst)
+	if bs == nil {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(string(bs))
+	if err != nil {
+		return err
+	}
+	gcm, err := a.aead()
+	if err != nil {
+		return err
+	}
+	if len(data) < gcm.NonceSize() {
+		return errs.ErrInvalidCiphertext
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return err
+	}
+	switch d := dst.(type) {
+	case *string:
+		*d = string(plain)
+	case *[]byte:
+		*d = plain
+	default:
+		return errs.NewErrUnsupportedAESType(dst)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type Celsius float64
+
+type celsiusConverter struct{}
+
+func (celsiusConverter) ToDB(val any) (driver.Value, error) {
+	return float64(val.(Celsius)) * 10, nil
+}
+
+func (celsiusConverter) FromDB(src any, dst any) error {
+	*dst.(*Celsius) = Celsius(src.(float64) / 10)
+	return nil
+}
+
+type ConverterModel struct {
+	Tags   []string          `orm:"serializer=json"`
+	Attrs  map[string]string `orm:"serializer=gob"`
+	Secret string            `orm:"serializer=aes"`
+	Temp   Celsius
+	Plain  string
+}
+
+func TestConverter(t *testing.T) {
+	RegisterConverter[Celsius](celsiusConverter{})
+	r := NewRegistry()
+	m, err := r.Get(&ConverterModel{})
+	require.NoError(t, err)
+	assert.Nil(t, m.Fields["Plain"].Converter)
+	assert.Equal(t, celsiusConverter{}, m.Fields["Temp"].Converter)
+
+	_, err = r.Get(&struct {
+		Name string `orm:"serializer=xml"`
+	}{})
+	assert.Equal(t, errs.NewErrUnknownSerializer("xml"), err)
+
+	testCases := []struct {
+		name  string
+		field string
+		val   any
+	}{
+		{name: "json", field: "Tags", val: []string{"a", "b"}},
+		{name: "json nil", field: "Tags", val: []string(nil)},
+		{name: "gob", field: "Attrs", val: map[string]string{"k": "v"}},
+		{name: "aes", field: "Secret", val: "password"},
+		{name: "type", field: "Temp", val: Celsius(36.5)},
+	}
+	SetKeyProvider(func() ([]byte, error) {
+		return []byte("0123456789abcdef"), nil
+	})
+	defer SetKeyProvider(nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fd := m.Fields[tc.field]
+			v, err := fd.Value(tc.val)
+			require.NoError(t, err)
+			dst := &ConverterModel{Attrs: map[string]string{"old": "v"}}
+			var ptr any
+			switch tc.field {
+			case "Tags":
+				ptr = &dst.Tags
+			case "Attrs":
+				ptr = &dst.Attrs
+			case "Secret":
+				ptr = &dst.Secret
+			case "Temp":
+				ptr = &dst.Temp
+			}
+			s := fd.Scanner(ptr).(interface{ Scan(any) error })
+			require.NoError(t, s.Scan(v))
+			assert.Equal(t, tc.val, reflect.ValueOf(ptr).Elem().Interface())
+		})
+	}
+}
+
+func TestAESConverter(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := NewAESConverter(func() ([]byte, error) { return key, nil })
+	first, err := c.ToDB("secret")
+	require.NoError(t, err)
+	second, err := c.ToDB("secret")
+	require.NoError(t, err)
+	// 每次的 nonce 都不一样
+	assert.NotEqual(t, first, second)
+
+	var res string
+	require.NoError(t, c.FromDB(first, &res))
+	assert.Equal(t, "secret", res)
+	require.NoError(t, c.FromDB(nil, &res))
+	assert.Equal(t, "", res)
+
+	other := NewAESConverter(func() ([]byte, error) { return []byte("fedcba9876543210"), nil })
+	assert.Error(t, other.FromDB(first, &res))
+	assert.Equal(t, errs.ErrInvalidCiphertext, c.FromDB("AAAA", &res))
+
+	_, err = c.ToDB(123)
+	assert.Equal(t, errs.NewErrUnsupportedAESType(123), err)
+	var num int
+	assert.Equal(t, errs.NewErrUnsupportedAESType(&num), c.FromDB(first, &num))
+	assert.Equal(t, errs.NewErrUnsupportedSource(12), c.FromDB(12, &res))
+
+	keyErr := errors.New("kms unavailable")
+	broken := NewAESConverter(func() ([]byte, error) { return nil, keyErr })
+	_, err = broken.ToDB("secret")
+	assert.Equal(t, keyErr, err)
+
+	m, err := NewRegistry().Get(&ConverterModel{})
+	require.NoError(t, err)
+	_, err = m.Fields["Secret"].Value("secret")
+	assert.Equal(t, errs.ErrMissingKeyProvider, err)
+}
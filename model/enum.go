@@ -0,0 +1,170 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"orm/internal/errs"
+	"reflect"
+	"strconv"
+)
+
+const (
+	tagKeyEnum = "enum"
+
+	EnumString = "string"
+	EnumInt    = "int"
+)
+
+// Enum 是枚举类型，比如 type Status int8，String 返回枚举的文本。
+// 字段的类型实现了这个接口的时候，默认整数类型按照整数存储，字符串类型按照文本存储，
+// 也可以通过标签 orm:"enum=string" 或者 orm:"enum=int" 指定
+type Enum interface {
+	fmt.Stringer
+	// EnumValues 返回所有合法的值，元素的类型必须是实现接口的类型本身
+	EnumValues() []any
+}
+
+// UnknownEnumError 是写入或者读出了不在 EnumValues 里面的值
+type UnknownEnumError struct {
+	Type  reflect.Type
+	Value any
+}
+
+func (e *UnknownEnumError) Error() string {
+	return fmt.Sprintf("orm: %v 不是合法的 %s", e.Value, e.Type)
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+type enumConverter struct {
+	// typ 是枚举类型本身，字段是 *Status 这种指针的时候 ptr 为 true
+	typ reflect.Type
+	ptr bool
+	// toDB 的键是枚举的值，值是存到数据库里面的 string 或者 int64
+	toDB   map[any]any
+	fromDB map[any]reflect.Value
+	// values 按照 EnumValues 的顺序排列的数据库里面的值，生成 DDL 的时候使用
+	values []any
+}
+
+func newEnumConverter(typ reflect.Type, storage string) (*enumConverter, error) {
+	// 可以为 NULL 的枚举字段是指针，EnumValues 要在元素类型的零值上调用
+	ptr := typ.Kind() == reflect.Pointer
+	if ptr {
+		typ = typ.Elem()
+	}
+	if !typ.Implements(enumType) {
+		return nil, errs.NewErrInvalidTagContent(tagKeyEnum + "=" + storage)
+	}
+	isInt := isIntKind(typ.Kind())
+	if storage == "" {
+		storage = EnumString
+		if isInt {
+			storage = EnumInt
+		}
+	}
+	if storage != EnumString && (storage != EnumInt || !isInt) {
+		return nil, errs.NewErrInvalidTagContent(tagKeyEnum + "=" + storage)
+	}
+	vals := reflect.Zero(typ).Interface().(Enum).EnumValues()
+	res := &enumConverter{
+		typ:    typ,
+		ptr:    ptr,
+		toDB:   make(map[any]any, len(vals)),
+		fromDB: make(map[any]reflect.Value, len(vals)),
+		values: make([]any, 0, len(vals)),
+	}
+	for _, val := range vals {
+		rv := reflect.ValueOf(val)
+		if rv.Type() != typ {
+			return nil, &UnknownEnumError{Type: typ, Value: val}
+		}
+		var db any = val.(Enum).String()
+		if storage == EnumInt {
+			db = intOf(rv)
+		}
+		res.toDB[val] = db
+		res.fromDB[db] = rv
+		res.values = append(res.values, db)
+	}
+	return res, nil
+}
+
+func (e *enumConverter) Deterministic() {}
+
+// ToDB 既接受字段类型的值，也接受枚举类型本身的值，nil 指针写成 NULL
+func (e *enumConverter) ToDB(val any) (driver.Value, error) {
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Pointer && rv.Type().Elem() == e.typ {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		val = rv.Elem().Interface()
+	}
+	db, ok := e.toDB[val]
+	if !ok {
+		return nil, &UnknownEnumError{Type: e.typ, Value: val}
+	}
+	return db, nil
+}
+
+// FromDB NULL 会被转换成零值
+func (e *enumConverter) FromDB(src any, dst any) error {
+	var key any
+	switch v := src.(type) {
+	case nil:
+		setZero(dst)
+		return nil
+	case int64:
+		key = v
+	case []byte:
+		key = string(v)
+	default:
+		key = v
+	}
+	// MySQL 的整数有可能是以文本的形式返回的
+	if s, ok := key.(string); ok && e.isInt() {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return &UnknownEnumError{Type: e.typ, Value: src}
+		}
+		key = i
+	}
+	val, ok := e.fromDB[key]
+	if !ok {
+		return &UnknownEnumError{Type: e.typ, Value: key}
+	}
+	if e.ptr {
+		p := reflect.New(e.typ)
+		p.Elem().Set(val)
+		val = p
+	}
+	reflect.ValueOf(dst).Elem().Set(val)
+	return nil
+}
+
+func (e *enumConverter) isInt() bool {
+	if len(e.values) == 0 {
+		return false
+	}
+	_, ok := e.values[0].(int64)
+	return ok
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+func intOf(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	default:
+		return v.Int()
+	}
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type Status int8
+
+const (
+	StatusActive Status = iota + 1
+	StatusBlocked
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusActive:
+		return "active"
+	case StatusBlocked:
+		return "blocked"
+	default:
+		return "unknown"
+	}
+}
+
+func (s Status) EnumValues() []any {
+	return []any{StatusActive, StatusBlocked}
+}
+
+type Level string
+
+func (l Level) String() string {
+	return string(l)
+}
+
+func (l Level) EnumValues() []any {
+	return []any{Level("low"), Level("high")}
+}
+
+func TestEnum(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entity  any
+		field   string
+		val     any
+		wantDB  any
+		src     any
+		wantErr error
+		values  []any
+	}{
+		{
+			name: "int",
+			entity: &struct {
+				Status Status
+			}{},
+			field:  "Status",
+			val:    StatusBlocked,
+			wantDB: int64(2),
+			values: []any{int64(1), int64(2)},
+		},
+		{
+			name: "int as string",
+			entity: &struct {
+				Status Status `orm:"enum=string"`
+			}{},
+			field:  "Status",
+			val:    StatusActive,
+			wantDB: "active",
+			values: []any{"active", "blocked"},
+		},
+		{
+			name: "string",
+			entity: &struct {
+				Level Level
+			}{},
+			field:  "Level",
+			val:    Level("high"),
+			wantDB: "high",
+			values: []any{"low", "high"},
+		},
+		{
+			// MySQL 有时候用文本返回整数
+			name: "int from bytes",
+			entity: &struct {
+				Status Status
+			}{},
+			field:  "Status",
+			val:    StatusActive,
+			wantDB: int64(1),
+			src:    []byte("1"),
+			values: []any{int64(1), int64(2)},
+		},
+		{
+			// 可以为 NULL 的枚举
+			name: "pointer",
+			entity: &struct {
+				Status *Status `orm:"enum=string"`
+			}{},
+			field:  "Status",
+			val:    &[]Status{StatusBlocked}[0],
+			wantDB: "blocked",
+			values: []any{"active", "blocked"},
+		},
+		{
+			name: "nil pointer",
+			entity: &struct {
+				Status *Status
+			}{},
+			field:  "Status",
+			val:    (*Status)(nil),
+			values: []any{int64(1), int64(2)},
+		},
+		{
+			name: "string as int",
+			entity: &struct {
+				Level Level `orm:"enum=int"`
+			}{},
+			wantErr: errs.NewErrInvalidTagContent("enum=int"),
+		},
+		{
+			name: "not enum",
+			entity: &struct {
+				Age int `orm:"enum=int"`
+			}{},
+			wantErr: errs.NewErrInvalidTagContent("enum=int"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewRegistry().Get(tc.entity)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			fd := m.Fields[tc.field]
+			assert.Equal(t, tc.values, fd.EnumValues)
+			db, err := fd.Value(tc.val)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantDB, db)
+			src := tc.src
+			if src == nil {
+				src = db
+			}
+			dst := reflect.New(fd.Typ)
+			require.NoError(t, fd.Scanner(dst.Interface()).(interface{ Scan(any) error }).Scan(src))
+			assert.Equal(t, tc.val, dst.Elem().Interface())
+		})
+	}
+}
+
+func TestEnum_Unknown(t *testing.T) {
+	m, err := NewRegistry().Get(&struct{ Status Status }{})
+	require.NoError(t, err)
+	fd := m.Fields["Status"]
+	_, err = fd.Value(Status(9))
+	var enumErr *UnknownEnumError
+	require.True(t, errors.As(err, &enumErr))
+	assert.Equal(t, Status(9), enumErr.Value)
+
+	var s Status
+	err = fd.Scanner(&s).(interface{ Scan(any) error }).Scan(int64(3))
+	assert.Equal(t, &UnknownEnumError{Type: reflect.TypeOf(s), Value: int64(3)}, err)
+	err = fd.Scanner(&s).(interface{ Scan(any) error }).Scan("x")
+	assert.Equal(t, &UnknownEnumError{Type: reflect.TypeOf(s), Value: "x"}, err)
+}
@@ -14,8 +14,20 @@ type Model struct {
 
 const (
 	tagKeyColumn = "column"
+	// tagKeyType 是建表的时候使用的列类型，不设置的时候按照 Go 的类型推断
+	tagKeyType = "type"
 )
 
+// FieldByColumn 先按照列名查找字段，找不到的话再按照字段名查找，
+// 这样 SELECT COUNT(*) AS `Cnt` 这种直接用字段名做别名的列也能对上
+func (m *Model) FieldByColumn(col string) (*Field, bool) {
+	if fd, ok := m.Columns[col]; ok {
+		return fd, true
+	}
+	fd, ok := m.Fields[col]
+	return fd, ok
+}
+
 type ModelOpt func(*Model) error
 type Field struct {
 	ColName string
@@ -23,6 +35,14 @@ type Field struct {
 	Typ     reflect.Type
 	Offset  uintptr
 	Alias   string
+	// Converter 不为 nil 的时候读写数据库都要经过它
+	Converter Converter
+	// Serializer 是标签里面的 serializer
+	Serializer string
+	// ColType 是标签里面的 type
+	ColType string
+	// EnumValues 是枚举在数据库里面所有合法的值，string 或者 int64
+	EnumValues []any
 }
 
 func WithTableName(tableName string) ModelOpt {
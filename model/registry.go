@@ -66,7 +66,15 @@ func (r *registry) parseModel(entity any) (*Model, error) {
 		if columnName == "" {
 			columnName = underscoreName(fieldType.Name)
 		}
-		fi := &Field{ColName: columnName, Typ: fieldType.Type, GoName: fieldType.Name, Offset: fieldType.Offset}
+		c, err := converterOf(fieldType.Type, tags)
+		if err != nil {
+			return nil, err
+		}
+		fi := &Field{ColName: columnName, Typ: fieldType.Type, GoName: fieldType.Name, Offset: fieldType.Offset,
+			Converter: c, Serializer: tags[tagKeySerializer], ColType: tags[tagKeyType]}
+		if ec, ok := c.(*enumConverter); ok {
+			fi.EnumValues = ec.values
+		}
 		fields[fieldType.Name] = fi
 		columns[columnName] = fi
 		fieldArr = append(fieldArr, fi)
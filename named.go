@@ -0,0 +1,223 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"orm/internal/errs"
+	"reflect"
+	"strings"
+)
+
+// expandQuery 处理原生 SQL 里面的参数：
+// ? 按顺序绑定 args，:name 和 @name 通过 named 查找（named 为 nil 的时候原样保留），
+// 切片参数会被展开成 ?,?,?，这样 IN (?) 和 IN (:ids) 都能直接传切片。
+// 引号和注释里面的内容原样保留。
+// 返回的 SQL 统一使用 ? 作为占位符，最后再由 rebind 转换成方言的占位符
+func expandQuery(query string, args []any, named func(name string) (any, error)) (*Query, error) {
+	var sb strings.Builder
+	sb.Grow(len(query))
+	res := make([]any, 0, len(args))
+	argIdx := 0
+	bind := func(arg any) error {
+		vals, ok := expandArg(arg)
+		if !ok {
+			sb.WriteByte('?')
+			res = append(res, arg)
+			return nil
+		}
+		if len(vals) == 0 {
+			return errs.ErrEmptySliceArg
+		}
+		for i, v := range vals {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('?')
+			res = append(res, v)
+		}
+		return nil
+	}
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		if end, ok := skipLiteral(query, i); ok {
+			sb.WriteString(query[i:end])
+			i = end - 1
+			continue
+		}
+		switch {
+		case ch == '?':
+			if argIdx >= len(args) {
+				return nil, errs.NewErrRawArgsMismatch(len(args))
+			}
+			if err := bind(args[argIdx]); err != nil {
+				return nil, err
+			}
+			argIdx++
+		case (ch == ':' || ch == '@') && named != nil && i+1 < len(query) && isNameStart(query[i+1]) &&
+			(i == 0 || query[i-1] != ch):
+			// :: 是 PostgreSQL 的类型转换，@@ 是 MySQL 的系统变量，都不是命名参数
+			j := i + 1
+			for j < len(query) && isNamePart(query[j]) {
+				j++
+			}
+			arg, err := named(query[i+1 : j])
+			if err != nil {
+				return nil, err
+			}
+			if err = bind(arg); err != nil {
+				return nil, err
+			}
+			i = j - 1
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	if argIdx != len(args) {
+		return nil, errs.NewErrRawArgsMismatch(len(args))
+	}
+	return &Query{
+		SQL:  sb.String(),
+		Args: res,
+	}, nil
+}
+
+// hasSliceArg 判断有没有需要展开的参数
+func hasSliceArg(args []any) bool {
+	for _, arg := range args {
+		if _, ok := expandArg(arg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// expandArg 切片和数组需要展开，[]byte 和实现了 driver.Valuer 的类型除外
+func expandArg(arg any) ([]any, bool) {
+	if arg == nil {
+		return nil, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+	val := reflect.ValueOf(arg)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false
+	}
+	if val.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	res := make([]any, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		res = append(res, val.Index(i).Interface())
+	}
+	return res, true
+}
+
+// skipQuoted 返回引号结束之后的位置，两个连续的引号表示转义
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != quote {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+// skipLiteral 如果 i 是引号或者注释的开始，返回它结束之后的位置
+func skipLiteral(query string, i int) (int, bool) {
+	switch ch := query[i]; {
+	case ch == '\'' || ch == '"' || ch == '`':
+		return skipQuoted(query, i), true
+	case ch == '-' && strings.HasPrefix(query[i:], "--"):
+		end := strings.IndexByte(query[i:], '\n')
+		if end < 0 {
+			return len(query), true
+		}
+		return i + end, true
+	case ch == '/' && strings.HasPrefix(query[i:], "/*"):
+		end := strings.Index(query[i+2:], "*/")
+		if end < 0 {
+			return len(query), true
+		}
+		return i + 2 + end + 2, true
+	}
+	return 0, false
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isNamePart(ch byte) bool {
+	return isNameStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+// namedLookup 命名参数可以来自 map[string]any 或者结构体，
+// 结构体按照列名或者字段名查找
+func namedLookup(c core, arg any) (func(name string) (any, error), error) {
+	if m, ok := arg.(map[string]any); ok {
+		return func(name string) (any, error) {
+			val, ok := m[name]
+			if !ok {
+				return nil, errs.NewErrUnKnownNamedArg(name)
+			}
+			return val, nil
+		}, nil
+	}
+	val := reflect.ValueOf(arg)
+	if val.Kind() != reflect.Pointer {
+		// unsafe 的实现需要指针
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		arg = ptr.Interface()
+	}
+	m, err := c.r.Get(arg)
+	if err != nil {
+		return nil, err
+	}
+	v := c.creator(m, arg)
+	return func(name string) (any, error) {
+		fd, ok := m.FieldByColumn(name)
+		if !ok {
+			return nil, errs.NewErrUnKnownNamedArg(name)
+		}
+		return v.Field(fd.GoName)
+	}, nil
+}
+
+// rebind 把 ? 转换成方言的占位符，引号和注释里面的 ? 不会被转换。
+// 已经使用了 $1 这种占位符的原生 SQL 原样返回，这时候 ? 是 PostgreSQL jsonb 的操作符
+func rebind(d Dialect, query string) string {
+	if d.bindVar(1) == "?" {
+		return query
+	}
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	idx := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		if end, ok := skipLiteral(query, i); ok {
+			sb.WriteString(query[i:end])
+			i = end - 1
+			continue
+		}
+		switch ch {
+		case '$':
+			if i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9' {
+				return query
+			}
+			sb.WriteByte(ch)
+		case '?':
+			idx++
+			sb.WriteString(d.bindVar(idx))
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
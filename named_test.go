@@ -0,0 +1,231 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestRawQuerier_Build(t *testing.T) {
+	db := memoryDB(t)
+	testCases := []struct {
+		name      string
+		q         QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "positional",
+			q:    RawQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` = ?", 1),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` = ?",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "positional slice",
+			q:    RawQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` IN (?) AND `age` > ?", []int{1, 2, 3}, 18),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?) AND `age` > ?",
+				Args: []any{1, 2, 3, 18},
+			},
+		},
+		{
+			name: "bytes not expanded",
+			q:    RawQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `data` = ?", []byte("abc")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `data` = ?",
+				Args: []any{[]byte("abc")},
+			},
+		},
+		{
+			name:    "too few args",
+			q:       RawQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` IN (?) AND `age` = ?", []int{1}),
+			wantErr: errs.NewErrRawArgsMismatch(1),
+		},
+		{
+			// 没有切片参数和命名参数的时候原样返回
+			name: "pass through",
+			q:    RawQuery[TestModel](db, "SELECT * FROM test_model WHERE id = $1 AND data ? 'key'", 1),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM test_model WHERE id = $1 AND data ? 'key'",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "comments",
+			q: RawQuery[TestModel](db, "SELECT * FROM `test_model` -- id = ?\n"+
+				"WHERE /* age > ? */ `id` IN (?)", []int{1, 2}),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` -- id = ?\nWHERE /* age > ? */ `id` IN (?,?)",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "named comments",
+			q: NamedQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` = :id -- :age ?",
+				map[string]any{"id": 1}),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` = ? -- :age ?",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "empty slice",
+			q:       RawQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` IN (?)", []int{}),
+			wantErr: errs.ErrEmptySliceArg,
+		},
+		{
+			name: "named map",
+			q: NamedQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` IN (:ids) AND `first_name` = @name "+
+				"AND `last_name` = ':name' AND `age`::int > :age",
+				map[string]any{"ids": []int64{1, 2}, "name": "Tom", "age": 18}),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?) AND `first_name` = ? AND `last_name` = ':name' AND `age`::int > ?",
+				Args: []any{int64(1), int64(2), "Tom", 18},
+			},
+		},
+		{
+			name: "named struct",
+			q: NamedQuery[TestModel](db, "INSERT INTO `test_model`(`id`,`first_name`) VALUES (:id,:FirstName)",
+				TestModel{Id: 12, FirstName: "Tom"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model`(`id`,`first_name`) VALUES (?,?)",
+				Args: []any{int64(12), "Tom"},
+			},
+		},
+		{
+			name:    "unknown named arg",
+			q:       NamedQuery[TestModel](db, "SELECT * FROM `test_model` WHERE `id` = :uid", &TestModel{}),
+			wantErr: errs.NewErrUnKnownNamedArg("uid"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.q.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		wantSQL string
+	}{
+		{
+			name:    "mysql",
+			dialect: DialectMySQL,
+			query:   "SELECT * FROM `test_model` WHERE `id` = ? AND `age` > ?",
+			wantSQL: "SELECT * FROM `test_model` WHERE `id` = ? AND `age` > ?",
+		},
+		{
+			name:    "postgres",
+			dialect: DialectPostgreSQL,
+			query:   `SELECT * FROM "test_model" WHERE "id" = ? AND "first_name" <> '?' AND "age" > ?`,
+			wantSQL: `SELECT * FROM "test_model" WHERE "id" = $1 AND "first_name" <> '?' AND "age" > $2`,
+		},
+		{
+			name:    "postgres comments",
+			dialect: DialectPostgreSQL,
+			query:   "SELECT * FROM \"test_model\" /* ? */ WHERE \"id\" = ? -- ?",
+			wantSQL: "SELECT * FROM \"test_model\" /* ? */ WHERE \"id\" = $1 -- ?",
+		},
+		{
+			name:    "postgres numbered",
+			dialect: DialectPostgreSQL,
+			query:   `SELECT * FROM "test_model" WHERE "data" ? 'key' AND "id" = $1`,
+			wantSQL: `SELECT * FROM "test_model" WHERE "data" ? 'key' AND "id" = $1`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantSQL, rebind(tc.dialect, tc.query))
+		})
+	}
+}
+
+func TestNamedQuery_Postgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB, DBWithDialect(DialectPostgreSQL))
+	require.NoError(t, err)
+	mock.ExpectQuery(`SELECT * FROM test_model WHERE id IN ($1,$2) AND age > $3`).
+		WithArgs(1, 2, 18).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"}).
+			AddRow(1, "Tom", 19, "Jerry"))
+
+	res, err := NamedQuery[TestModel](db, "SELECT * FROM test_model WHERE id IN (:ids) AND age > :age",
+		map[string]any{"ids": []int{1, 2}, "age": 18}).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom", Age: 19, LastName: sql.NullString{String: "Jerry", Valid: true}}, res)
+}
+
+func TestRawQuery_Postgres(t *testing.T) {
+	testCases := []struct {
+		name    string
+		sql     string
+		args    []any
+		wantSQL string
+	}{
+		{
+			name:    "numbered",
+			sql:     "SELECT * FROM test_model WHERE id = $1 AND age > $2",
+			args:    []any{1, 18},
+			wantSQL: "SELECT * FROM test_model WHERE id = $1 AND age > $2",
+		},
+		{
+			name:    "jsonb operator",
+			sql:     "SELECT * FROM test_model WHERE data ? 'key' AND id = $1",
+			args:    []any{1},
+			wantSQL: "SELECT * FROM test_model WHERE data ? 'key' AND id = $1",
+		},
+		{
+			name:    "jsonb operator without args",
+			sql:     "SELECT * FROM test_model WHERE data ? 'key'",
+			wantSQL: "SELECT * FROM test_model WHERE data ? 'key'",
+		},
+		{
+			name:    "comments",
+			sql:     "SELECT * FROM test_model /* ? */ WHERE id = ? -- ?",
+			args:    []any{1},
+			wantSQL: "SELECT * FROM test_model /* ? */ WHERE id = $1 -- ?",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB, DBWithDialect(DialectPostgreSQL))
+			require.NoError(t, err)
+			exp := mock.ExpectQuery(tc.wantSQL)
+			if len(tc.args) > 0 {
+				args := make([]driver.Value, 0, len(tc.args))
+				for _, arg := range tc.args {
+					args = append(args, arg)
+				}
+				exp.WithArgs(args...)
+			}
+			exp.WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"}).
+				AddRow(1, "Tom", 19, "Jerry"))
+
+			_, err = RawQuery[TestModel](db, tc.sql, tc.args...).Get(context.Background())
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package ormtest
+
+import (
+	"database/sql/driver"
+	"flag"
+	"fmt"
+	"orm"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// update 加上包名做前缀，避免和使用方自己定义的 -update 冲突
+var update = flag.Bool("ormtest.update", false, "ormtest: 用这次记录的语句重新生成 golden 文件")
+
+// updateEnv 设置成 1 的时候和 -ormtest.update 一样，方便在不能加参数的地方使用
+const updateEnv = "ORMTEST_UPDATE"
+
+func shouldUpdate() bool {
+	return *update || os.Getenv(updateEnv) == "1"
+}
+
+// AssertGolden 把 queries 和 testdata/<测试名>.golden 比较，
+// 使用 go test -ormtest.update 或者 ORMTEST_UPDATE=1 重新生成。
+// 占位符会被统一成 ?，不同方言的结果可以直接比较
+func AssertGolden(t testing.TB, queries []orm.Query) bool {
+	t.Helper()
+	path := filepath.Join("testdata", t.Name()+".golden")
+	got := FormatQueries(queries)
+	if shouldUpdate() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ormtest: 创建目录失败: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("ormtest: 写入 golden 文件失败: %v", err)
+			return false
+		}
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("ormtest: 读取 golden 文件失败，使用 -ormtest.update 生成: %v", err)
+		return false
+	}
+	return assert.Equal(t, string(want), got, "ormtest: 和 %s 不一致，确认修改正确之后使用 -ormtest.update 重新生成", path)
+}
+
+// FormatQueries 把语句格式化成 golden 文件的内容，每条语句一段：
+//
+//	-- 1
+//	SELECT * FROM `order` WHERE `id` = ?;
+//	args: 1
+func FormatQueries(queries []orm.Query) string {
+	var sb strings.Builder
+	for i, q := range queries {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("-- ")
+		sb.WriteString(strconv.Itoa(i + 1))
+		sb.WriteByte('\n')
+		sb.WriteString(NormalizeSQL(q.SQL))
+		sb.WriteByte('\n')
+		if len(q.Args) > 0 {
+			sb.WriteString("args: ")
+			for j, arg := range q.Args {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				sb.WriteString(formatArg(arg))
+			}
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// NormalizeSQL 把 $1、@p1、:1 这种方言的占位符换成 ?，引号里面的内容和 PostgreSQL 的 :: 不受影响
+func NormalizeSQL(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			j := i + 1
+			for j < len(query) && query[j] != ch {
+				j++
+			}
+			if j < len(query) {
+				j++
+			}
+			sb.WriteString(query[i:j])
+			i = j - 1
+		case ch == ':' && i+1 < len(query) && query[i+1] == ':':
+			sb.WriteString("::")
+			i++
+		case ch == '$' || ch == ':' || (ch == '@' && i+1 < len(query) && query[i+1] == 'p'):
+			j := i + 1
+			if ch == '@' {
+				j++
+			}
+			start := j
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j == start {
+				sb.WriteByte(ch)
+				continue
+			}
+			sb.WriteByte('?')
+			i = j - 1
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+func formatArg(arg any) string {
+	if v, ok := arg.(driver.Valuer); ok {
+		val, err := v.Value()
+		if err != nil {
+			return fmt.Sprintf("<%v>", err)
+		}
+		arg = val
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,130 @@
+package ormtest
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+func TestSession_Golden(t *testing.T) {
+	s := NewSQLiteSession(t, &Order{}, &User{})
+	s.LoadFixtures("testdata/orders.yaml")
+	ctx := context.Background()
+
+	err := orm.NewInserter[User](s).Values(&User{Id: 11, Name: "Jerry",
+		CreatedAt: time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)}).Exec(ctx).Err()
+	require.NoError(t, err)
+	_, err = orm.NewSelector[Order](s).Where(orm.C("UserId").EQ(10), orm.C("Status").In("paid", "new")).
+		OrderBy(orm.Desc("Id")).Limit(10).GetMulti(ctx)
+	require.NoError(t, err)
+	err = orm.NewUpdater[Order](s).Set(orm.Assign("Status", "refunded")).Where(orm.C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+	_, err = orm.RawQuery[Order](s, "SELECT * FROM `order` WHERE `status` = ?", "refunded").Get(ctx)
+	require.NoError(t, err)
+	AssertRowCount[Order](s, 2)
+	s.AssertGolden()
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "postgres",
+			query: `SELECT * FROM "order" WHERE "id" = $1 AND "status" IN ($2,$13);`,
+			want:  `SELECT * FROM "order" WHERE "id" = ? AND "status" IN (?,?);`,
+		},
+		{
+			name:  "sql server and oracle",
+			query: "UPDATE t SET a = @p1 WHERE b = :2;",
+			want:  "UPDATE t SET a = ? WHERE b = ?;",
+		},
+		{
+			name:  "quoted and cast",
+			query: `SELECT '$1', "@p2", col::text, @@version FROM t WHERE a = $1::jsonb;`,
+			want:  `SELECT '$1', "@p2", col::text, @@version FROM t WHERE a = ?::jsonb;`,
+		},
+		{
+			name:  "question mark",
+			query: "SELECT * FROM `t` WHERE `id` = ?;",
+			want:  "SELECT * FROM `t` WHERE `id` = ?;",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NormalizeSQL(tc.query))
+		})
+	}
+}
+
+func TestFormatQueries(t *testing.T) {
+	res := FormatQueries([]orm.Query{
+		{SQL: `SELECT * FROM "t" WHERE "a" = $1 AND "b" = $2;`, Args: []any{"x", sql.NullString{}}},
+		{SQL: "DELETE FROM `t`;"},
+		{SQL: "INSERT INTO `t` VALUES (?,?);", Args: []any{[]byte("ab"), time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	})
+	assert.Equal(t, `-- 1
+SELECT * FROM "t" WHERE "a" = ? AND "b" = ?;
+args: "x", NULL
+
+-- 2
+DELETE FROM `+"`t`"+`;
+
+-- 3
+INSERT INTO `+"`t`"+` VALUES (?,?);
+args: 0x6162, 2023-01-02T03:04:05Z
+`, res)
+}
+
+// named 用来指定 golden 文件的名字
+type named struct {
+	*recorder
+	name string
+}
+
+func (n named) Name() string {
+	return n.name
+}
+
+func TestAssertGolden_Failures(t *testing.T) {
+	r := &recorder{TB: t}
+	assert.False(t, AssertGolden(named{recorder: r, name: "missing"}, nil))
+	require.Len(t, r.msgs, 1)
+	assert.Contains(t, r.msgs[0], "使用 -ormtest.update 生成")
+
+	// golden 文件里面是 SELECT * FROM `order`;
+	r.msgs = nil
+	assert.False(t, AssertGolden(named{recorder: r, name: "mismatch"}, []orm.Query{{SQL: "SELECT * FROM `user`;"}}))
+	require.Len(t, r.msgs, 1)
+	assert.Contains(t, r.msgs[0], "确认修改正确之后使用 -ormtest.update 重新生成")
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	// 使用方自己定义 -update 不会冲突
+	assert.NotPanics(t, func() {
+		flag.Bool("update", false, "")
+	})
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	t.Setenv(updateEnv, "1")
+	queries := []orm.Query{{SQL: "SELECT * FROM `user`;"}}
+	assert.True(t, AssertGolden(named{recorder: &recorder{TB: t}, name: "update"}, queries))
+	data, err := os.ReadFile(filepath.Join("testdata", "update.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, FormatQueries(queries), string(data))
+}
@@ -0,0 +1,232 @@
+// Package ormtest 提供基于内存 SQLite 的测试工具，用来代替手写 sqlmock 的期望
+package ormtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"orm"
+	"orm/middleware"
+	"orm/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v3"
+)
+
+var seq int64
+
+// Session 是一个测试独占的内存 SQLite 数据库，可以直接作为 orm.Session 使用，
+// 经过它执行的语句都会被记录下来，建表和加载测试数据的语句除外
+type Session struct {
+	*orm.DB
+	t testing.TB
+	r model.Registry
+	// setup 和 DB 是同一个库，用来建表和加载测试数据，不经过中间件
+	setup    *orm.DB
+	recorder *middleware.QueryRecorder
+	// tables 是表名到模型的映射，加载测试数据的时候用来校验表和列
+	tables map[string]*model.Model
+}
+
+// NewSQLiteSession 创建一个新的内存 SQLite 数据库，按照 models 建表，
+// models 是模型的指针，比如 &User{}。测试结束的时候自动关闭，数据库也随之销毁
+func NewSQLiteSession(t testing.TB, models ...any) *Session {
+	t.Helper()
+	r := model.NewRegistry()
+	// 每个 Session 用一个新的库，cache=shared 让连接池里面的连接看到同一个库
+	dsn := fmt.Sprintf("file:ormtest_%d?mode=memory&cache=shared", atomic.AddInt64(&seq, 1))
+	setup, err := orm.Open("sqlite3", dsn, orm.DBWithDialect(orm.DialectSQLite), orm.DBWithRegistry(r))
+	if err != nil {
+		t.Fatalf("ormtest: 打开 SQLite 失败: %v", err)
+	}
+	rec := middleware.NewQueryRecorder()
+	db, err := orm.Open("sqlite3", dsn, orm.DBWithDialect(orm.DialectSQLite), orm.DBWithRegistry(r),
+		orm.DBWithMiddleware(rec.Build()))
+	if err != nil {
+		t.Fatalf("ormtest: 打开 SQLite 失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+		_ = setup.Close()
+	})
+	s := &Session{DB: db, t: t, r: r, setup: setup, recorder: rec, tables: make(map[string]*model.Model, len(models))}
+	for _, m := range models {
+		md, err := r.Get(m)
+		if err != nil {
+			t.Fatalf("ormtest: 解析模型 %T 失败: %v", m, err)
+		}
+		if err = orm.NewCreateTableOf(setup, m).Exec(context.Background()).Err(); err != nil {
+			t.Fatalf("ormtest: 创建表 %s 失败: %v", md.TableName, err)
+		}
+		s.tables[md.TableName] = md
+	}
+	return s
+}
+
+// LoadFixtures 加载测试数据，按照扩展名区分 YAML 和 JSON。
+// 文件的格式是表名到行的映射，每一行是列名到值的映射：
+//
+//	order:
+//	  - id: 1
+//	    status: paid
+func (s *Session) LoadFixtures(files ...string) {
+	s.t.Helper()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			s.t.Fatalf("ormtest: 读取测试数据失败: %v", err)
+		}
+		var fixture map[string][]map[string]any
+		switch ext := strings.ToLower(filepath.Ext(f)); ext {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &fixture)
+		case ".json":
+			fixture, err = decodeJSON(data)
+		default:
+			err = fmt.Errorf("不支持的扩展名 %s", ext)
+		}
+		if err != nil {
+			s.t.Fatalf("ormtest: 解析测试数据 %s 失败: %v", f, err)
+		}
+		if err = s.insert(fixture); err != nil {
+			s.t.Fatalf("ormtest: 加载测试数据 %s 失败: %v", f, err)
+		}
+	}
+}
+
+// insert 按照表名排序插入，同一个表按照文件里面的顺序插入
+func (s *Session) insert(fixture map[string][]map[string]any) error {
+	tables := make([]string, 0, len(fixture))
+	for tbl := range fixture {
+		tables = append(tables, tbl)
+	}
+	sort.Strings(tables)
+	for _, tbl := range tables {
+		m, ok := s.tables[tbl]
+		if !ok {
+			return fmt.Errorf("表 %s 不在 NewSQLiteSession 的模型里面", tbl)
+		}
+		for _, row := range fixture[tbl] {
+			query, args, err := insertSQL(m, row)
+			if err != nil {
+				return err
+			}
+			if _, err = orm.RawQuery[any](s.setup, query, args...).Exec(context.Background()).RowsAffected(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// insertSQL 没有给出的列使用 Go 的零值，和插入一个零值的结构体一样，主键 Id 除外
+func insertSQL(m *model.Model, row map[string]any) (string, []any, error) {
+	for col := range row {
+		if _, ok := m.Columns[col]; !ok {
+			return "", nil, fmt.Errorf("表 %s 没有列 %s", m.TableName, col)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO `" + m.TableName + "`(")
+	args := make([]any, 0, len(m.FieldArr))
+	for _, fd := range m.FieldArr {
+		val, ok := row[fd.ColName]
+		if !ok {
+			if fd.GoName == "Id" {
+				continue
+			}
+			var err error
+			if val, err = fd.Value(reflect.Zero(fd.Typ).Interface()); err != nil {
+				return "", nil, err
+			}
+		}
+		if len(args) > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString("`" + fd.ColName + "`")
+		args = append(args, val)
+	}
+	sb.WriteString(") VALUES (")
+	sb.WriteString(strings.TrimSuffix(strings.Repeat("?,", len(args)), ","))
+	sb.WriteString(");")
+	return sb.String(), args, nil
+}
+
+// decodeJSON 整数保持整数，不变成 float64
+func decodeJSON(data []byte) (map[string][]map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var res map[string][]map[string]any
+	if err := dec.Decode(&res); err != nil {
+		return nil, err
+	}
+	for _, rows := range res {
+		for _, row := range rows {
+			for k, v := range row {
+				n, ok := v.(json.Number)
+				if !ok {
+					continue
+				}
+				if i, err := n.Int64(); err == nil {
+					row[k] = i
+				} else if f, err := n.Float64(); err == nil {
+					row[k] = f
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// Queries 返回经过这个 Session 执行的语句
+func (s *Session) Queries() []orm.Query {
+	return s.recorder.Queries()
+}
+
+// ResetQueries 清空记录的语句
+func (s *Session) ResetQueries() {
+	s.recorder.Reset()
+}
+
+// AssertGolden 把记录的语句和 golden 文件比较，见 AssertGolden
+func (s *Session) AssertGolden() bool {
+	s.t.Helper()
+	return AssertGolden(s.t, s.Queries())
+}
+
+// AssertRowCount 断言 T 对应的表里面满足 ps 的行数是 want
+func AssertRowCount[T any](s *Session, want int64, ps ...orm.Predicate) bool {
+	s.t.Helper()
+	cnt, err := orm.NewSelector[T](s.setup).Where(ps...).Count(context.Background())
+	if err != nil {
+		s.t.Errorf("ormtest: 统计 %T 的行数失败: %v", new(T), err)
+		return false
+	}
+	if cnt != want {
+		s.t.Errorf("ormtest: %T 的行数是 %d，期望 %d", new(T), cnt, want)
+		return false
+	}
+	return true
+}
+
+// AssertExists 断言 T 对应的表里面有满足 ps 的行
+func AssertExists[T any](s *Session, ps ...orm.Predicate) bool {
+	s.t.Helper()
+	ok, err := orm.NewSelector[T](s.setup).Where(ps...).Exists(context.Background())
+	if err != nil {
+		s.t.Errorf("ormtest: 查询 %T 失败: %v", new(T), err)
+		return false
+	}
+	if !ok {
+		s.t.Errorf("ormtest: %T 里面没有满足条件的行", new(T))
+		return false
+	}
+	return true
+}
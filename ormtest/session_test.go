@@ -0,0 +1,84 @@
+package ormtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm"
+)
+
+type Order struct {
+	Id     int64
+	UserId int64
+	Status string
+	Amount float64
+}
+
+type User struct {
+	Id        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+// recorder 记录断言失败的信息，而不是让测试失败
+type recorder struct {
+	testing.TB
+	msgs []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.msgs = append(r.msgs, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.msgs = append(r.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestSQLiteSession(t *testing.T) {
+	s := NewSQLiteSession(t, &Order{}, &User{})
+	s.LoadFixtures("testdata/orders.yaml", "testdata/orders.json")
+
+	AssertRowCount[Order](s, 3)
+	AssertRowCount[Order](s, 2, orm.C("Status").EQ("paid"))
+	AssertExists[Order](s, orm.C("Id").EQ(3), orm.C("Amount").EQ(12.5))
+	AssertExists[User](s, orm.C("Name").EQ("Tom"))
+
+	u, err := orm.NewSelector[User](s).Where(orm.C("Id").EQ(10)).Get(context.Background())
+	require.NoError(t, err)
+	assert.True(t, time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC).Equal(u.CreatedAt))
+
+	err = orm.NewDeleter[Order](s).Where(orm.C("UserId").EQ(10)).Exec(context.Background()).Err()
+	require.NoError(t, err)
+	AssertRowCount[Order](s, 1)
+}
+
+// 每个 Session 都是独立的库
+func TestSQLiteSession_Isolated(t *testing.T) {
+	s1 := NewSQLiteSession(t, &Order{})
+	s2 := NewSQLiteSession(t, &Order{})
+	s1.LoadFixtures("testdata/orders.json")
+	AssertRowCount[Order](s1, 1)
+	AssertRowCount[Order](s2, 0)
+}
+
+func TestSQLiteSession_Failures(t *testing.T) {
+	r := &recorder{TB: t}
+	s := NewSQLiteSession(r, &Order{})
+	s.LoadFixtures("testdata/unknown_column.yaml", "testdata/orders.yaml")
+	assert.Equal(t, []string{
+		"ormtest: 加载测试数据 testdata/unknown_column.yaml 失败: 表 order 没有列 price",
+		"ormtest: 加载测试数据 testdata/orders.yaml 失败: 表 user 不在 NewSQLiteSession 的模型里面",
+	}, r.msgs)
+
+	r.msgs = nil
+	assert.False(t, AssertRowCount[Order](s, 1))
+	assert.False(t, AssertExists[Order](s, orm.C("Status").EQ("refunded")))
+	assert.Equal(t, []string{
+		"ormtest: *ormtest.Order 的行数是 2，期望 1",
+		"ormtest: *ormtest.Order 里面没有满足条件的行",
+	}, r.msgs)
+}
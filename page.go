@@ -0,0 +1,190 @@
+package orm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"orm/internal/errs"
+	"reflect"
+)
+
+// Page 是 Paginate 的结果，Page 从 1 开始
+type Page[T any] struct {
+	Items []*T
+	Total int64
+	Page  int
+	Size  int
+}
+
+// CursorPage 是 Seek 的结果，Next 为空说明没有下一页了
+type CursorPage[T any] struct {
+	Items []*T
+	Next  string
+}
+
+// rowValue 是 (a,b) 这种行构造器，用于游标分页
+type rowValue struct {
+	cols []Column
+}
+
+func (r rowValue) expr() {}
+
+// Count 返回满足条件的行数，会去掉 ORDER BY、LIMIT、OFFSET 和游标。
+// 有 GROUP BY 的时候统计的是分组的数量
+func (s *Selector[T]) Count(ctx context.Context) (int64, error) {
+	c := s.Clone()
+	c.orderBy, c.limit, c.offset, c.after = nil, 0, 0, ""
+	q := c
+	if len(c.groupBy) > 0 {
+		// 外层只是包一下，Scope 已经加在子查询里面了
+		q = &Selector[T]{
+			builder:  builder{sess: s.sess, core: s.core, unscoped: true},
+			table:    SubQuery{s: c, alias: "t"},
+			cacheTTL: s.cacheTTL,
+		}
+	}
+	q.columns = []Selectable{Raw("COUNT(*)")}
+	res, err := Scan[int64](ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return res[0], nil
+}
+
+// Exists 判断有没有满足条件的行
+func (s *Selector[T]) Exists(ctx context.Context) (bool, error) {
+	c := s.Clone()
+	c.columns = []Selectable{Raw("1")}
+	c.orderBy, c.limit, c.offset = nil, 1, 0
+	res, err := Scan[int64](ctx, c)
+	return len(res) > 0, err
+}
+
+// Paginate 查询第 page 页，每页 size 行，同时返回总行数
+func (s *Selector[T]) Paginate(ctx context.Context, page, size int) (*Page[T], error) {
+	if page < 1 || size < 1 {
+		return nil, errs.ErrInvalidPage
+	}
+	total, err := s.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &Page[T]{Items: []*T{}, Total: total, Page: page, Size: size}
+	offset := (page - 1) * size
+	if int64(offset) >= total {
+		return res, nil
+	}
+	c := s.Clone()
+	c.limit, c.offset = size, offset
+	res.Items, err = c.GetMulti(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// After 从游标的位置开始查询，也就是 (a,b) > (?,?)，a 和 b 是 OrderBy 的列。
+// 所有列都是 DESC 的时候用 <，不支持混合的排序方向
+func (s *Selector[T]) After(cursor string) *Selector[T] {
+	s.after = cursor
+	return s
+}
+
+// Seek 按照游标查询下一页，返回的 Next 可以传给 After，
+// 只有设置了 Limit 并且这一页是满的时候才有 Next
+func (s *Selector[T]) Seek(ctx context.Context) (*CursorPage[T], error) {
+	items, err := s.GetMulti(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &CursorPage[T]{Items: items}
+	if s.limit > 0 && len(items) == s.limit {
+		res.Next, err = s.Cursor(items[len(items)-1])
+	}
+	return res, err
+}
+
+// Cursor 返回 entity 在 OrderBy 的列上面的游标，
+// 保存的是字段本身的值，解析的时候按照字段的类型还原
+func (s *Selector[T]) Cursor(entity *T) (string, error) {
+	if len(s.orderBy) == 0 {
+		return "", errs.ErrCursorOrder
+	}
+	m, err := s.r.Get(entity)
+	if err != nil {
+		return "", err
+	}
+	val := s.creator(m, entity)
+	vals := make([]any, 0, len(s.orderBy))
+	for _, o := range s.orderBy {
+		v, err := val.Field(o.col)
+		if err != nil {
+			return "", err
+		}
+		vals = append(vals, v)
+	}
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// cursorPredicate 把游标转换成 (a,b) > (?,?)
+func (s *Selector[T]) cursorPredicate() (Predicate, error) {
+	if len(s.orderBy) == 0 {
+		return Predicate{}, errs.ErrCursorOrder
+	}
+	cmp := opGT
+	if s.orderBy[0].order == "DESC" {
+		cmp = opLT
+	}
+	for _, o := range s.orderBy {
+		if o.order != s.orderBy[0].order {
+			return Predicate{}, errs.ErrCursorOrder
+		}
+	}
+	raws, err := decodeCursor(s.after)
+	if err != nil {
+		return Predicate{}, err
+	}
+	if len(raws) != len(s.orderBy) {
+		return Predicate{}, errs.ErrInvalidCursor
+	}
+	cols := make([]Column, 0, len(s.orderBy))
+	vals := make([]any, 0, len(s.orderBy))
+	for i, o := range s.orderBy {
+		fd, ok := s.Model.Fields[o.col]
+		if !ok {
+			return Predicate{}, errs.NewErrUnKnownField(o.col)
+		}
+		// 按照字段的类型还原，time.Time 和 []byte 才不会变成字符串
+		val := reflect.New(fd.Typ)
+		if err = json.Unmarshal(raws[i], val.Interface()); err != nil {
+			return Predicate{}, errs.ErrInvalidCursor
+		}
+		arg, err := predicateArg(fd, val.Elem().Interface())
+		if err != nil {
+			return Predicate{}, err
+		}
+		cols = append(cols, C(o.col))
+		vals = append(vals, arg)
+	}
+	return Predicate{left: rowValue{cols: cols}, op: cmp, right: values{values: vals}}, nil
+}
+
+// decodeCursor 返回每一列还没有解析的值
+func decodeCursor(cursor string) ([]json.RawMessage, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+	var vals []json.RawMessage
+	if err = json.Unmarshal(data, &vals); err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+	return vals, nil
+}
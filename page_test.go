@@ -0,0 +1,193 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestSelector_Cursor(t *testing.T) {
+	db := memoryDB(t)
+	cursor, err := NewSelector[TestModel](db).OrderBy(Asc("Age"), Asc("Id")).
+		Cursor(&TestModel{Id: 3, Age: 18})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		s       QueryBuilder
+		wantSQL string
+		args    []any
+		wantErr error
+	}{
+		{
+			name:    "asc",
+			s:       NewSelector[TestModel](db).Where(C("FirstName").EQ("Tom")).OrderBy(Asc("Age"), Asc("Id")).After(cursor).Limit(10),
+			wantSQL: "SELECT * FROM `test_model` WHERE (`first_name` = ?) AND ((`age`,`id`) > (?,?)) ORDER BY `age` ASC,`id` ASC LIMIT ?;",
+			args:    []any{"Tom", int8(18), int64(3), 10},
+		},
+		{
+			name:    "desc",
+			s:       NewSelector[TestModel](db).OrderBy(Desc("Age"), Desc("Id")).After(cursor),
+			wantSQL: "SELECT * FROM `test_model` WHERE (`age`,`id`) < (?,?) ORDER BY `age` DESC,`id` DESC;",
+			args:    []any{int8(18), int64(3)},
+		},
+		{
+			name:    "mixed order",
+			s:       NewSelector[TestModel](db).OrderBy(Asc("Age"), Desc("Id")).After(cursor),
+			wantErr: errs.ErrCursorOrder,
+		},
+		{
+			name:    "no order",
+			s:       NewSelector[TestModel](db).After(cursor),
+			wantErr: errs.ErrCursorOrder,
+		},
+		{
+			name:    "column mismatch",
+			s:       NewSelector[TestModel](db).OrderBy(Asc("Id")).After(cursor),
+			wantErr: errs.ErrInvalidCursor,
+		},
+		{
+			name:    "invalid cursor",
+			s:       NewSelector[TestModel](db).OrderBy(Asc("Id")).After("!!"),
+			wantErr: errs.ErrInvalidCursor,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.s.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Equal(t, tc.args, q.Args)
+		})
+	}
+}
+
+func TestSelector_Paginate(t *testing.T) {
+	db, err := Open("sqlite3", "file:paginate.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(TestModel{}.CreateSQL())
+	require.NoError(t, err)
+	ctx := context.Background()
+	vals := make([]*TestModel, 0, 25)
+	for i := 1; i <= 25; i++ {
+		vals = append(vals, &TestModel{Id: int64(i), FirstName: fmt.Sprintf("user%d", i),
+			Age: int8(i % 3), LastName: sql.NullString{String: "Doe", Valid: true}})
+	}
+	require.NoError(t, NewInserter[TestModel](db).Values(vals...).Exec(ctx).Err())
+
+	cnt, err := NewSelector[TestModel](db).Where(C("Age").EQ(0)).OrderBy(Asc("Id")).Limit(2).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), cnt)
+	cnt, err = NewSelector[TestModel](db).Select(C("Age")).GroupBy(C("Age")).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	ok, err := NewSelector[TestModel](db).Where(C("Id").EQ(25)).Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = NewSelector[TestModel](db).Where(C("Id").EQ(26)).Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	page, err := NewSelector[TestModel](db).OrderBy(Asc("Id")).Paginate(ctx, 3, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), page.Total)
+	assert.Equal(t, 3, page.Page)
+	assert.Equal(t, 10, page.Size)
+	assert.Equal(t, vals[20:], page.Items)
+	page, err = NewSelector[TestModel](db).Paginate(ctx, 4, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{}, page.Items)
+	_, err = NewSelector[TestModel](db).Paginate(ctx, 0, 10)
+	assert.Equal(t, errs.ErrInvalidPage, err)
+
+	// 按照游标翻完所有的页
+	var (
+		res    []*TestModel
+		cursor string
+	)
+	for i := 0; i < 10; i++ {
+		s := NewSelector[TestModel](db).OrderBy(Desc("Age"), Desc("Id")).Limit(10)
+		if cursor != "" {
+			s = s.After(cursor)
+		}
+		p, err := s.Seek(ctx)
+		require.NoError(t, err)
+		res = append(res, p.Items...)
+		if cursor = p.Next; cursor == "" {
+			break
+		}
+	}
+	require.Len(t, res, 25)
+	assert.Equal(t, int64(23), res[0].Id)
+	assert.Equal(t, int64(3), res[24].Id)
+}
+
+func TestSelector_Seek_Time(t *testing.T) {
+	db, err := Open("sqlite3", "file:seek_time.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	require.NoError(t, NewCreateTable[Account](db).Exec(ctx).Err())
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	vals := make([]*Account, 0, 5)
+	for i := 1; i <= 5; i++ {
+		vals = append(vals, &Account{Id: int64(i), Status: UserActive, Role: UserActive,
+			Avatar: []byte{byte(i)}, CreatedAt: start.Add(time.Duration(5-i) * time.Hour)})
+	}
+	require.NoError(t, NewInserter[Account](db).Values(vals...).Exec(ctx).Err())
+
+	// 游标里面的时间要按照 time.Time 还原，不然比较的是字符串
+	var (
+		res    []int64
+		cursor string
+	)
+	for i := 0; i < 5; i++ {
+		s := NewSelector[Account](db).OrderBy(Asc("CreatedAt"), Asc("Id")).Limit(2)
+		if cursor != "" {
+			s = s.After(cursor)
+		}
+		p, err := s.Seek(ctx)
+		require.NoError(t, err)
+		for _, a := range p.Items {
+			res = append(res, a.Id)
+		}
+		if cursor = p.Next; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []int64{5, 4, 3, 2, 1}, res)
+
+	cursor, err = NewSelector[Account](db).OrderBy(Asc("Avatar")).Cursor(vals[0])
+	require.NoError(t, err)
+	q, err := NewSelector[Account](db).OrderBy(Asc("Avatar")).After(cursor).Build()
+	require.NoError(t, err)
+	assert.Equal(t, []any{[]byte{1}}, q.Args)
+}
+
+func TestRawQuerier_GetMulti(t *testing.T) {
+	db, err := Open("sqlite3", "file:raw_get_multi.db?cache=shared&mode=memory")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(TestModel{}.CreateSQL())
+	require.NoError(t, err)
+	_, err = db.db.Exec("INSERT INTO `test_model` VALUES (1,'Tom',18,'Jerry'),(2,'Tim',19,'Jerry')")
+	require.NoError(t, err)
+
+	res, err := RawQuery[TestModel](db, "SELECT * FROM `test_model` ORDER BY `id`").GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*TestModel{
+		{Id: 1, FirstName: "Tom", Age: 18, LastName: sql.NullString{String: "Jerry", Valid: true}},
+		{Id: 2, FirstName: "Tim", Age: 19, LastName: sql.NullString{String: "Jerry", Valid: true}},
+	}, res)
+}
@@ -4,12 +4,16 @@ type op string
 
 const (
 	opEq    op = "="
+	opNEQ   op = "!="
 	opNot   op = "NOT"
 	opAnd   op = "AND"
 	opOr    op = "OR"
 	opLT    op = "<"
+	opLTEQ  op = "<="
 	opGT    op = ">"
+	opGTEQ  op = ">="
 	opIN    op = "IN"
+	opLike  op = "LIKE"
 	opExist op = "EXIST"
 )
 
@@ -72,3 +76,11 @@ func valueOf(val any) Expression {
 		return value{value: val}
 	}
 }
+
+// values 是 IN 后面的值列表，构造成 (?,?,?)
+type values struct {
+	values []any
+}
+
+func (v values) expr() {
+}
@@ -8,15 +8,26 @@ import (
 type RawQuerier[T any] struct {
 	sql  string
 	args []any
+	// named 命名参数的来源，map[string]any 或者结构体
+	named any
 	core
 	sess Session
 }
 
+// Build 没有命名参数和切片参数的时候原样返回，不解析 SQL
 func (r *RawQuerier[T]) Build() (*Query, error) {
-	return &Query{
-		SQL:  r.sql,
-		Args: r.args,
-	}, nil
+	if r.named == nil && !hasSliceArg(r.args) {
+		return &Query{SQL: r.sql, Args: r.args}, nil
+	}
+	var named func(name string) (any, error)
+	if r.named != nil {
+		var err error
+		named, err = namedLookup(r.core, r.named)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expandQuery(r.sql, r.args, named)
 }
 
 func RawQuery[T any](sess Session, query string, args ...any) *RawQuerier[T] {
@@ -29,6 +40,18 @@ func RawQuery[T any](sess Session, query string, args ...any) *RawQuerier[T] {
 	}
 }
 
+// NamedQuery 和 RawQuery 一样，但是使用 :name 或者 @name 作为占位符，
+// 参数从 arg 里面按照名字查找，arg 可以是 map[string]any 或者结构体
+func NamedQuery[T any](sess Session, query string, arg any) *RawQuerier[T] {
+	core := sess.getCore()
+	return &RawQuerier[T]{
+		sql:   query,
+		named: arg,
+		sess:  sess,
+		core:  core,
+	}
+}
+
 func (r *RawQuerier[T]) Exec(ctx context.Context) sql.Result {
 	res := exec[T](ctx, r.sess, r.core, &QueryContext{
 		Type:    "RAW",
@@ -55,7 +78,26 @@ func (r *RawQuerier[T]) Get(ctx context.Context) (*T, error) {
 	return nil, res.Err
 }
 
+func (r *RawQuerier[T]) scanContext(ctx context.Context) (context.Context, Session, *QueryContext, error) {
+	m, err := modelOf[T](r.r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ctx, r.sess, &QueryContext{
+		Type:    "RAW",
+		Builder: r,
+		Model:   m,
+	}, nil
+}
+
 func (r *RawQuerier[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	//TODO implement me
-	panic("implement me")
+	res := getMulti[T](ctx, r.sess, r.core, &QueryContext{
+		Type:    "RAW",
+		Builder: r,
+		Model:   r.Model,
+	})
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return *res.Result.(*[]*T), nil
 }
@@ -0,0 +1,149 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"orm/model"
+	"reflect"
+	"time"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Scannable 是 Scan 能够执行的查询，Selector 和 RawQuerier 都实现了这个接口
+type Scannable interface {
+	QueryBuilder
+	scanContext(ctx context.Context) (context.Context, Session, *QueryContext, error)
+}
+
+// Scan 执行查询，并且把每一行都转换成 R。
+// R 可以是任意结构体，按照标签、列名或者字段名匹配列；
+// 也可以是 map[string]any，或者 int64、string 这种只有一列的基本类型
+func Scan[R any](ctx context.Context, q Scannable) ([]R, error) {
+	ctx, sess, qc, err := q.scanContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sess, c, err := sess.getCore().route(ctx, sess, qc.Type == "RAW")
+	if err != nil {
+		return nil, err
+	}
+	m, err := modelOf[R](c.r)
+	if err != nil {
+		return nil, err
+	}
+	qc.ResultType = reflect.TypeOf([]R{})
+	qc.ctx, qc.sess, qc.core = ctx, sess, c
+	qc.Builder = bindContext(ctx, qc.Builder)
+	res := c.chain(func(ctx context.Context, qc *QueryContext) *QueryResult {
+		return scanHandler[R](ctx, sess, c, m, qc)
+	})(ctx, qc)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return *res.Result.(*[]R), nil
+}
+
+func scanHandler[R any](ctx context.Context, sess Session, c core, m *model.Model, qc *QueryContext) *QueryResult {
+	q, err := qc.Query()
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	rows, err := c.queryContext(ctx, sess, q)
+	if err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	defer rows.Close()
+	res := make([]R, 0, 8)
+	for rows.Next() {
+		r, err := scanRow[R](c, m, rows)
+		if err != nil {
+			return &QueryResult{
+				Err: err,
+			}
+		}
+		res = append(res, *r)
+	}
+	if err = rows.Err(); err != nil {
+		return &QueryResult{
+			Err: err,
+		}
+	}
+	return &QueryResult{
+		Result: &res,
+	}
+}
+
+// modelOf 返回 T 的模型，T 是基本类型或者 map 的时候不需要模型，返回 nil
+func modelOf[T any](r model.Registry) (*model.Model, error) {
+	typ := reflect.TypeOf(new(T)).Elem()
+	if isScalar(typ) || typ.Kind() == reflect.Map {
+		return nil, nil
+	}
+	return r.Get(new(T))
+}
+
+// isScalar 判断 typ 是否只能对应一列，
+// 实现了 sql.Scanner 的结构体和 time.Time 也被认为是基本类型
+func isScalar(typ reflect.Type) bool {
+	if reflect.PointerTo(typ).Implements(scannerType) || typ == timeType {
+		return true
+	}
+	return typ.Kind() != reflect.Struct
+}
+
+// scanRow 把当前行转换成 T，m 是 T 的模型
+func scanRow[T any](c core, m *model.Model, rows *sql.Rows) (*T, error) {
+	tp := new(T)
+	if mp, ok := any(tp).(*map[string]any); ok {
+		res, err := scanMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		*mp = res
+		return tp, nil
+	}
+	if m == nil {
+		return tp, rows.Scan(tp)
+	}
+	val := c.creator(m, tp)
+	if err := val.SetColumns(rows); err != nil {
+		return nil, err
+	}
+	if c.tracker != nil {
+		return tp, c.tracker.snapshot(m, tp, val)
+	}
+	return tp, nil
+}
+
+// scanMap 以列名为键，[]byte 会被转换成 string
+func scanMap(rows *sql.Rows) (map[string]any, error) {
+	cs, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]any, len(cs))
+	ptrs := make([]any, len(cs))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err = rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	res := make(map[string]any, len(cs))
+	for i, c := range cs {
+		if bs, ok := vals[i].([]byte); ok {
+			res[c] = string(bs)
+			continue
+		}
+		res[c] = vals[i]
+	}
+	return res, nil
+}
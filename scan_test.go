@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestScan(t *testing.T) {
+	type AgeCount struct {
+		Age int8
+		Cnt int64 `orm:"column=cnt"`
+	}
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		scan    func(db *DB) (any, error)
+		wantRes any
+		wantErr error
+	}{
+		{
+			name: "dto",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT `age`,COUNT\\(`id`\\) AS `cnt` FROM `test_model` GROUP BY `age`;").
+					WillReturnRows(sqlmock.NewRows([]string{"age", "cnt"}).AddRow(18, 2).AddRow(19, 1))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[AgeCount](context.Background(), NewSelector[TestModel](db).
+					Select(C("Age"), Count("Id").As("cnt")).GroupBy(C("Age")))
+			},
+			wantRes: []AgeCount{{Age: 18, Cnt: 2}, {Age: 19, Cnt: 1}},
+		},
+		{
+			name: "unknown column",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").
+					WillReturnRows(sqlmock.NewRows([]string{"age", "total"}).AddRow(18, 2))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[AgeCount](context.Background(), NewSelector[TestModel](db).
+					Select(C("Age"), Count("Id").As("total")).GroupBy(C("Age")))
+			},
+			wantErr: errs.NewErrUnKnownColumn("total"),
+		},
+		{
+			name: "map",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).
+						AddRow(1, []byte("Tom")).AddRow(2, "Jerry"))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[map[string]any](context.Background(), NewSelector[TestModel](db).
+					Select(C("Id"), C("FirstName")))
+			},
+			wantRes: []map[string]any{
+				{"id": int64(1), "first_name": "Tom"},
+				{"id": int64(2), "first_name": "Jerry"},
+			},
+		},
+		{
+			name: "scalar",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT `first_name` FROM `test_model`;").
+					WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("Tom").AddRow("Jerry"))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[string](context.Background(), NewSelector[TestModel](db).Select(C("FirstName")))
+			},
+			wantRes: []string{"Tom", "Jerry"},
+		},
+		{
+			name: "scanner",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT last_name FROM test_model").
+					WillReturnRows(sqlmock.NewRows([]string{"last_name"}).AddRow("Jerry").AddRow(nil))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[sql.NullString](context.Background(),
+					RawQuery[TestModel](db, "SELECT last_name FROM test_model"))
+			},
+			wantRes: []sql.NullString{{String: "Jerry", Valid: true}, {}},
+		},
+		{
+			name: "no rows",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			scan: func(db *DB) (any, error) {
+				return Scan[int64](context.Background(), NewSelector[TestModel](db).Select(C("Id")))
+			},
+			wantRes: []int64{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+			db, err := OpenDB(mockDB)
+			require.NoError(t, err)
+			tc.mock(mock)
+			res, err := tc.scan(db)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantRes, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRawQuery_Scalar(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM `test_model`").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(12))
+
+	cnt, err := RawQuery[int64](db, "SELECT COUNT(*) FROM `test_model`").Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), *cnt)
+}
+
+func TestDBIgnoreUnknownColumns(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB, DBIgnoreUnknownColumns())
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "cnt"}).AddRow(1, "Tom", 2))
+
+	res, err := NewSelector[TestModel](db).Select(C("Id"), C("FirstName"), Count("Id").As("cnt")).
+		GroupBy(C("Id")).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{Id: 1, FirstName: "Tom"}, res)
+}
@@ -0,0 +1,181 @@
+package orm
+
+import (
+	"context"
+	"orm/internal/errs"
+)
+
+// Scope 返回需要自动加到 SELECT、UPDATE、DELETE 的 WHERE 里面的条件，
+// qc.Model 是正在构造的语句对应的模型，qc.Context() 是执行语句的 ctx
+type Scope func(qc *QueryContext) []Predicate
+
+// tenant 是多租户的配置，field 是租户字段名
+type tenant struct {
+	field string
+	value func(ctx context.Context) (any, bool)
+}
+
+// DBWithScope 注册对所有模型都生效的 Scope
+func DBWithScope(scopes ...Scope) DBOptions {
+	return func(db *DB) {
+		db.scopes = append(db.scopes, scopes...)
+	}
+}
+
+// DBWithModelScope 注册只对 T 生效的 Scope
+func DBWithModelScope[T any](scope Scope) DBOptions {
+	return func(db *DB) {
+		db.scopes = append(db.scopes, func(qc *QueryContext) []Predicate {
+			m, err := db.r.Get(new(T))
+			if err != nil || qc.Model == nil || qc.Model.TableName != m.TableName {
+				return nil
+			}
+			return scope(qc)
+		})
+	}
+}
+
+// DBWithTenant 开启多租户隔离，field 是租户字段名，value 从 ctx 里面取出当前的租户。
+// 所有带有这个字段的模型在查询、更新、删除的时候都会加上 field = 租户，JOIN 进来的表也一样，
+// 插入和按照实体更新的时候会用租户覆盖这个字段，通过 Assign 或者 upsert 修改这个字段会返回 errs.ErrTenantAssign。
+// ctx 里面没有租户的时候返回 errs.ErrMissingTenant，需要跳过的时候使用 Unscoped
+func DBWithTenant(field string, value func(ctx context.Context) (any, bool)) DBOptions {
+	return func(db *DB) {
+		db.tenant = &tenant{field: field, value: value}
+	}
+}
+
+// bindContext 返回绑定了 ctx 的副本，让构造语句的时候可以拿到执行语句的 ctx，
+// 不修改 qb 本身，所以同一个 qb 可以同时在多个 goroutine 里面执行
+func bindContext(ctx context.Context, qb QueryBuilder) QueryBuilder {
+	if c, ok := qb.(interface {
+		withContext(ctx context.Context) QueryBuilder
+	}); ok {
+		return c.withContext(ctx)
+	}
+	return qb
+}
+
+func (b *builder) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// scoped 返回加上了租户条件之后的 table，以及需要放到 WHERE 里面的 Scope 和租户条件，不会修改传入的 table
+func (b *builder) scoped(typ string, qb QueryBuilder, table TableReference) (TableReference, []Predicate, error) {
+	if b.unscoped {
+		return table, nil, nil
+	}
+	var ps []Predicate
+	if len(b.scopes) > 0 {
+		qc := &QueryContext{Type: typ, Builder: qb, Model: b.Model, ctx: b.context()}
+		for _, scope := range b.scopes {
+			ps = append(ps, scope(qc)...)
+		}
+	}
+	table, tps, err := b.scopeTenant(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, append(ps, tps...), nil
+}
+
+// scopeTenant 给 table 里面所有带有租户字段的表加上租户条件，JOIN 进来的表也要隔离。
+// 返回的条件加在 WHERE 里面；外连接里面可能没有匹配的一边，条件加在这个 JOIN 的 ON 里面，
+// 不然 WHERE 会把外连接变成内连接。table 里面找不到 b.Model 的时候，b.Model 的租户列不带表名
+func (b *builder) scopeTenant(table TableReference) (TableReference, []Predicate, error) {
+	if b.tenant == nil {
+		return table, nil, nil
+	}
+	val, ok := b.tenant.value(b.context())
+	var where []Predicate
+	found := false
+	table = b.tenantJoin(table, val, &where, &found)
+	if _, has := b.Model.Fields[b.tenant.field]; has && !found {
+		where = append(where, C(b.tenant.field).EQ(val))
+	}
+	if len(where) > 0 && !ok {
+		return nil, nil, errs.ErrMissingTenant
+	}
+	return table, where, nil
+}
+
+// tenantJoin 把 table 里面的表的租户条件加到 ps 里面，返回的 Join 是副本
+func (b *builder) tenantJoin(table TableReference, val any, ps *[]Predicate, found *bool) TableReference {
+	switch t := table.(type) {
+	case Table:
+		m, err := b.r.Get(t.entity)
+		if err != nil {
+			return t
+		}
+		if m.TableName == b.Model.TableName {
+			*found = true
+		}
+		if _, ok := m.Fields[b.tenant.field]; ok {
+			*ps = append(*ps, t.C(b.tenant.field).EQ(val))
+		}
+		return t
+	case Join:
+		var on []Predicate
+		left, right := ps, ps
+		switch t.typ {
+		case "LEFT JOIN":
+			right = &on
+		case "RIGHT JOIN":
+			left = &on
+		}
+		t.left = b.tenantJoin(t.left, val, left, found)
+		t.right = b.tenantJoin(t.right, val, right, found)
+		if len(on) > 0 {
+			if len(t.using) > 0 {
+				// USING 不能再加 ON，只能放到外面，没有匹配的行会被过滤掉
+				*ps = append(*ps, on...)
+			} else {
+				t.on = append(clip(t.on), on...)
+			}
+		}
+		return t
+	default:
+		return table
+	}
+}
+
+// tenantValue 返回当前的租户，第二个返回值表示这个模型需不需要隔离
+func (b *builder) tenantValue() (any, bool, error) {
+	if b.unscoped || b.tenant == nil {
+		return nil, false, nil
+	}
+	if _, ok := b.Model.Fields[b.tenant.field]; !ok {
+		return nil, false, nil
+	}
+	val, ok := b.tenant.value(b.context())
+	if !ok {
+		return nil, false, errs.ErrMissingTenant
+	}
+	return val, true, nil
+}
+
+// checkTenantAssigns 多租户隔离的时候不允许通过赋值修改租户字段，避免把数据挪到别的租户下面
+func (b *builder) checkTenantAssigns(assigns []Assignable) error {
+	_, ok, err := b.tenantValue()
+	if err != nil || !ok {
+		return err
+	}
+	for _, assign := range assigns {
+		var name string
+		switch a := assign.(type) {
+		case Assignment:
+			name = a.col
+		case Column:
+			name = a.name
+		case JSONAssignment:
+			name = a.col.name
+		}
+		if name == b.tenant.field {
+			return errs.ErrTenantAssign
+		}
+	}
+	return nil
+}
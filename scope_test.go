@@ -0,0 +1,249 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type Post struct {
+	Id       int64
+	TenantId int64
+	Title    string
+	Deleted  bool
+}
+
+type Comment struct {
+	Id       int64
+	PostId   int64
+	TenantId int64
+}
+
+type tenantKey struct{}
+
+func tenantOf(ctx context.Context) (any, bool) {
+	val, ok := ctx.Value(tenantKey{}).(int64)
+	return val, ok
+}
+
+func TestScope_Build(t *testing.T) {
+	db := memoryDB(t,
+		DBWithScope(func(qc *QueryContext) []Predicate {
+			if qc.Type == "DELETE" {
+				return nil
+			}
+			return []Predicate{C("Id").GT(0)}
+		}),
+		DBWithModelScope[Post](func(qc *QueryContext) []Predicate {
+			return []Predicate{C("Deleted").EQ(false)}
+		}),
+		DBWithTenant("TenantId", tenantOf))
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+	p := TableOf(&Post{}).As("p")
+	c := TableOf(&Comment{}).As("c")
+
+	testCases := []struct {
+		name    string
+		ctx     context.Context
+		b       QueryBuilder
+		wantSQL string
+		wantErr error
+		args    []any
+	}{
+		{
+			name:    "select",
+			ctx:     ctx,
+			b:       NewSelector[Post](db).Where(C("Title").EQ("orm")),
+			wantSQL: "SELECT * FROM `post` WHERE (((`title` = ?) AND (`id` > ?)) AND (`deleted` = ?)) AND (`tenant_id` = ?);",
+			args:    []any{"orm", 0, false, int64(7)},
+		},
+		{
+			// 没有租户字段的模型只使用全局的 Scope
+			name:    "select other model",
+			ctx:     ctx,
+			b:       NewSelector[TestModel](db),
+			wantSQL: "SELECT * FROM `test_model` WHERE `id` > ?;",
+			args:    []any{0},
+		},
+		{
+			name:    "select with alias",
+			ctx:     ctx,
+			b:       NewSelector[Post](db).From(p),
+			wantSQL: "SELECT * FROM `post` AS `p` WHERE ((`id` > ?) AND (`deleted` = ?)) AND (`p`.`tenant_id` = ?);",
+			args:    []any{0, false, int64(7)},
+		},
+		{
+			// JOIN 进来的表也要隔离
+			name: "select join",
+			ctx:  ctx,
+			b:    NewSelector[Post](db).From(p.Join(c).On(p.C("Id").EQ(c.C("PostId")))),
+			wantSQL: "SELECT * FROM (`post` AS `p` JOIN `comment` AS `c` ON `p`.`id` = `c`.`post_id`) " +
+				"WHERE (((`id` > ?) AND (`deleted` = ?)) AND (`p`.`tenant_id` = ?)) AND (`c`.`tenant_id` = ?);",
+			args: []any{0, false, int64(7), int64(7)},
+		},
+		{
+			// 外连接可能没有匹配的一边，条件放在 ON 里面
+			name: "select left join",
+			ctx:  ctx,
+			b:    NewSelector[Post](db).From(p.LeftJoin(c).On(p.C("Id").EQ(c.C("PostId")))),
+			wantSQL: "SELECT * FROM (`post` AS `p` LEFT JOIN `comment` AS `c` ON (`p`.`id` = `c`.`post_id`) AND (`c`.`tenant_id` = ?)) " +
+				"WHERE ((`id` > ?) AND (`deleted` = ?)) AND (`p`.`tenant_id` = ?);",
+			args: []any{int64(7), 0, false, int64(7)},
+		},
+		{
+			name: "select right join",
+			ctx:  ctx,
+			b:    NewSelector[Comment](db).From(p.RightJoin(c).On(p.C("Id").EQ(c.C("PostId")))),
+			wantSQL: "SELECT * FROM (`post` AS `p` RIGHT JOIN `comment` AS `c` ON (`p`.`id` = `c`.`post_id`) AND (`p`.`tenant_id` = ?)) " +
+				"WHERE (`id` > ?) AND (`c`.`tenant_id` = ?);",
+			args: []any{int64(7), 0, int64(7)},
+		},
+		{
+			name:    "unscoped",
+			ctx:     ctx,
+			b:       NewSelector[Post](db).Unscoped(),
+			wantSQL: "SELECT * FROM `post`;",
+		},
+		{
+			name:    "missing tenant",
+			ctx:     context.Background(),
+			b:       NewSelector[Post](db),
+			wantErr: errs.ErrMissingTenant,
+		},
+		{
+			// 子查询的错误不能被忽略，不然会生成没有隔离的 SQL
+			name:    "subquery missing tenant",
+			ctx:     context.Background(),
+			b:       NewSelector[TestModel](db).Where(Exist(NewSelector[Post](db).AsSubQuery())),
+			wantErr: errs.ErrMissingTenant,
+		},
+		{
+			name: "in subquery missing tenant",
+			ctx:  context.Background(),
+			b: NewSelector[TestModel](db).
+				Where(C("Id").InQuery(NewSelector[Post](db).Select(C("Id")).AsSubQuery())),
+			wantErr: errs.ErrMissingTenant,
+		},
+		{
+			name:    "update",
+			ctx:     ctx,
+			b:       NewUpdater[Post](db).Set(Assign("Title", "new")).Where(C("Id").EQ(1)),
+			wantSQL: "UPDATE `post` SET `title`=? WHERE (((`id` = ?) AND (`id` > ?)) AND (`deleted` = ?)) AND (`tenant_id` = ?);",
+			args:    []any{"new", 1, 0, false, int64(7)},
+		},
+		{
+			// 按照实体更新的时候租户字段使用当前的租户
+			name: "update entity",
+			ctx:  ctx,
+			b:    NewUpdater[Post](db).Update(&Post{Id: 1, TenantId: 8, Title: "a"}).Where(C("Id").EQ(1)),
+			wantSQL: "UPDATE `post` SET `id`=?,`tenant_id`=?,`title`=?,`deleted`=? " +
+				"WHERE (((`id` = ?) AND (`id` > ?)) AND (`deleted` = ?)) AND (`tenant_id` = ?);",
+			args: []any{int64(1), int64(7), "a", false, 1, 0, false, int64(7)},
+		},
+		{
+			name:    "update tenant",
+			ctx:     ctx,
+			b:       NewUpdater[Post](db).Set(Assign("TenantId", 8)).Where(C("Id").EQ(1)),
+			wantErr: errs.ErrTenantAssign,
+		},
+		{
+			name:    "update tenant unscoped",
+			ctx:     ctx,
+			b:       NewUpdater[Post](db).Set(Assign("TenantId", 8)).Where(C("Id").EQ(1)).Unscoped(),
+			wantSQL: "UPDATE `post` SET `tenant_id`=? WHERE `id` = ?;",
+			args:    []any{8, 1},
+		},
+		{
+			name:    "delete",
+			ctx:     ctx,
+			b:       NewDeleter[Post](db).Where(C("Id").EQ(1)),
+			wantSQL: "DELETE FROM `post` WHERE ((`id` = ?) AND (`deleted` = ?)) AND (`tenant_id` = ?);",
+			args:    []any{1, false, int64(7)},
+		},
+		{
+			name: "insert",
+			ctx:  ctx,
+			b: NewInserter[Post](db).Values(&Post{Id: 1, TenantId: 8, Title: "a"},
+				&Post{Id: 2, Title: "b"}),
+			wantSQL: "INSERT INTO `post`(`id`,`tenant_id`,`title`,`deleted`) VALUES (?,?,?,?),(?,?,?,?);",
+			args:    []any{int64(1), int64(7), "a", false, int64(2), int64(7), "b", false},
+		},
+		{
+			// 指定的列里面没有租户字段的时候自动加上
+			name:    "insert columns",
+			ctx:     ctx,
+			b:       NewInserter[Post](db).Columns("Id", "Title").Values(&Post{Id: 1, Title: "a"}),
+			wantSQL: "INSERT INTO `post`(`id`,`title`,`tenant_id`) VALUES (?,?,?);",
+			args:    []any{int64(1), "a", int64(7)},
+		},
+		{
+			name:    "insert unscoped",
+			ctx:     context.Background(),
+			b:       NewInserter[Post](db).Values(&Post{Id: 1, TenantId: 8}).Unscoped(),
+			wantSQL: "INSERT INTO `post`(`id`,`tenant_id`,`title`,`deleted`) VALUES (?,?,?,?);",
+			args:    []any{int64(1), int64(8), "", false},
+		},
+		{
+			name: "upsert tenant",
+			ctx:  ctx,
+			b: NewInserter[Post](db).Values(&Post{Id: 1}).OnDuplicateKey().
+				Update(Assign("Title", "a"), Assign("TenantId", 8)),
+			wantErr: errs.ErrTenantAssign,
+		},
+		{
+			name:    "upsert values tenant",
+			ctx:     ctx,
+			b:       NewInserter[Post](db).Values(&Post{Id: 1}).OnDuplicateKey().Update(C("TenantId")),
+			wantErr: errs.ErrTenantAssign,
+		},
+		{
+			name:    "insert missing tenant",
+			ctx:     context.Background(),
+			b:       NewInserter[Post](db).Values(&Post{Id: 1}),
+			wantErr: errs.ErrMissingTenant,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := bindContext(tc.ctx, tc.b).Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Equal(t, tc.args, q.Args)
+		})
+	}
+}
+
+func TestScope_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB, DBWithTenant("TenantId", tenantOf))
+	require.NoError(t, err)
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	mockRows := sqlmock.NewRows([]string{"id", "tenant_id", "title", "deleted"})
+	mockRows.AddRow(1, 7, "orm", false)
+	mock.ExpectQuery("SELECT \\* FROM `post` WHERE \\(`id` = \\?\\) AND \\(`tenant_id` = \\?\\);").
+		WithArgs(1, 7).WillReturnRows(mockRows)
+	res, err := NewSelector[Post](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Post{Id: 1, TenantId: 7, Title: "orm"}, res)
+
+	// 忘记写 Where 也会被隔离
+	mock.ExpectExec("DELETE FROM `post` WHERE `tenant_id` = \\?;").
+		WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 3))
+	affected, err := NewDeleter[Post](db).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	err = NewDeleter[Post](db).Exec(context.Background()).Err()
+	assert.Equal(t, errs.ErrMissingTenant, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
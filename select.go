@@ -3,6 +3,7 @@ package orm
 import (
 	"context"
 	"orm/internal/errs"
+	"time"
 )
 
 type Selectable interface {
@@ -20,9 +21,17 @@ type Selector[T any] struct {
 	offset  int
 	limit   int
 	table   TableReference
+	// cacheTTL 大于 0 的时候允许查询缓存中间件缓存结果
+	cacheTTL time.Duration
+	// after 是游标分页的游标
+	after string
+	index *indexHint
 }
 
 func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
+	if s.cacheTTL > 0 {
+		ctx = WithQueryCache(ctx, s.cacheTTL)
+	}
 	res := get[T](ctx, s.sess, s.core, &QueryContext{
 		Type:    "SELECT",
 		Builder: s,
@@ -34,9 +43,38 @@ func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
 	return nil, res.Err
 }
 
+func (s *Selector[T]) scanContext(ctx context.Context) (context.Context, Session, *QueryContext, error) {
+	m := s.Model
+	if m == nil {
+		var err error
+		m, err = s.r.Get(new(T))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if s.cacheTTL > 0 {
+		ctx = WithQueryCache(ctx, s.cacheTTL)
+	}
+	return ctx, s.sess, &QueryContext{
+		Type:    "SELECT",
+		Builder: s,
+		Model:   m,
+	}, nil
+}
+
 func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
-	//TODO implement me
-	panic("implement me")
+	if s.cacheTTL > 0 {
+		ctx = WithQueryCache(ctx, s.cacheTTL)
+	}
+	res := getMulti[T](ctx, s.sess, s.core, &QueryContext{
+		Type:    "SELECT",
+		Builder: s,
+		Model:   s.Model,
+	})
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	return *res.Result.(*[]*T), nil
 }
 
 func NewSelector[T any](sess Session) *Selector[T] {
@@ -77,12 +115,29 @@ func (s *Selector[T]) buildColumns() error {
 		case RawExpr:
 			s.sb.WriteString(expr.raw)
 			s.addArg(expr.args...)
+		case JSONExpr:
+			if err := s.dialect.buildJSON(&s.builder, expr); err != nil {
+				return err
+			}
+			if len(expr.alias) != 0 {
+				s.sb.WriteString(" AS ")
+				s.quote(expr.alias)
+			}
 		}
 	}
 	return nil
 }
 
 func (s *Selector[T]) Build() (*Query, error) {
+	stmt, err := s.statement()
+	if err != nil {
+		return nil, err
+	}
+	return s.render(stmt)
+}
+
+func (s *Selector[T]) statement() (*Statement, error) {
+	s = s.Clone()
 	if s.Model == nil {
 		var (
 			t   T
@@ -93,7 +148,31 @@ func (s *Selector[T]) Build() (*Query, error) {
 			return nil, err
 		}
 	}
-	s.sb.Reset()
+	table, scopes, err := s.scoped("SELECT", s, s.table)
+	if err != nil {
+		return nil, err
+	}
+	where := s.where
+	if s.after != "" {
+		p, err := s.cursorPredicate()
+		if err != nil {
+			return nil, err
+		}
+		where = append(where[:len(where):len(where)], p)
+	}
+	stmt := s.newStatement("SELECT")
+	stmt.Table, stmt.Columns, stmt.Where, stmt.Scopes = table, s.columns, where, scopes
+	stmt.OrderBy, stmt.Limit, stmt.Offset = s.orderBy, s.limit, s.offset
+	return stmt, s.rewrite(stmt, "")
+}
+
+func (s *Selector[T]) render(stmt *Statement) (*Query, error) {
+	s = s.Clone()
+	if err := s.apply(stmt); err != nil {
+		return nil, err
+	}
+	s.table, s.columns = stmt.Table, stmt.Columns
+	s.orderBy, s.limit, s.offset = stmt.OrderBy, stmt.Limit, stmt.Offset
 	s.sb.WriteString("SELECT ")
 	err := s.buildColumns()
 	if err != nil {
@@ -103,9 +182,17 @@ func (s *Selector[T]) Build() (*Query, error) {
 	if err := s.buildTable(s.table); err != nil {
 		return nil, err
 	}
-	if len(s.where) > 0 {
+	if s.index != nil {
+		switch s.table.(type) {
+		case nil, Table:
+			s.dialect.buildIndexHint(&s.builder, *s.index)
+		default:
+			return nil, errs.ErrIndexHintTable
+		}
+	}
+	if where := stmt.predicates(); len(where) > 0 {
 		s.sb.WriteString(" WHERE ")
-		err = s.buildPredicates(s.where)
+		err = s.buildPredicates(where)
 		if err != nil {
 			return nil, err
 		}
@@ -146,66 +233,7 @@ func (s *Selector[T]) Build() (*Query, error) {
 		s.addArg(s.offset)
 	}
 	s.sb.WriteByte(';')
-	return &Query{
-		SQL:  s.sb.String(),
-		Args: s.args,
-	}, nil
-}
-
-func (s *Selector[T]) buildTable(table TableReference) error {
-	switch t := table.(type) {
-	case nil:
-		s.quote(s.Model.TableName)
-	case Table:
-		m, err := s.r.Get(t.entity)
-		if err != nil {
-			return err
-		}
-		s.quote(m.TableName)
-		if t.alias != "" {
-			s.sb.WriteString(" AS ")
-			s.quote(t.alias)
-		}
-	case Join:
-		s.sb.WriteByte('(')
-		err := s.buildTable(t.left)
-		if err != nil {
-			return err
-		}
-		s.sb.WriteByte(' ')
-		s.sb.WriteString(t.typ)
-		s.sb.WriteByte(' ')
-		err = s.buildTable(t.right)
-		if err != nil {
-			return err
-		}
-		if len(t.using) > 0 {
-			s.sb.WriteString(" USING (")
-			for i, u := range t.using {
-				if i > 0 {
-					s.sb.WriteByte(',')
-				}
-				err = s.buildColumn(Column{name: u})
-				if err != nil {
-					return err
-				}
-			}
-			s.sb.WriteByte(')')
-		}
-		if len(t.on) > 0 {
-			s.sb.WriteString(" ON ")
-			err = s.buildPredicates(t.on)
-			if err != nil {
-				return err
-			}
-		}
-		s.sb.WriteByte(')')
-	case SubQuery:
-		return s.buildSubQuery(t)
-	default:
-		return errs.NewErrUnSupportedTable(t)
-	}
-	return nil
+	return s.build()
 }
 
 func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
@@ -213,6 +241,12 @@ func (s *Selector[T]) Where(ps ...Predicate) *Selector[T] {
 	return s
 }
 
+// Unscoped 不使用 Scope 和多租户隔离
+func (s *Selector[T]) Unscoped() *Selector[T] {
+	s.unscoped = true
+	return s
+}
+
 func (s *Selector[T]) GroupBy(cols ...Column) *Selector[T] {
 	s.groupBy = cols
 	return s
@@ -238,6 +272,12 @@ func (s *Selector[T]) Select(cols ...Selectable) *Selector[T] {
 	return s
 }
 
+// Cache 允许查询缓存中间件把结果缓存 ttl 这么久
+func (s *Selector[T]) Cache(ttl time.Duration) *Selector[T] {
+	s.cacheTTL = ttl
+	return s
+}
+
 func (s *Selector[T]) From(table TableReference) *Selector[T] {
 	s.table = table
 	return s
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"orm/internal/errs"
 	"orm/internal/valuer"
+	"reflect"
 	"testing"
 )
 
@@ -346,6 +347,90 @@ func TestSelector_Build(t *testing.T) {
 	}
 }
 
+func TestSelector_Converter(t *testing.T) {
+	type Ticket struct {
+		Id     int64
+		Role   UserStatus `orm:"enum=string"`
+		Status *UserStatus
+	}
+	db := memoryDB(t)
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "eq",
+			builder: NewSelector[Ticket](db).Where(C("Role").EQ(UserActive)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `ticket` WHERE `role` = ?;",
+				Args: []any{"active"},
+			},
+		},
+		{
+			name:    "in",
+			builder: NewSelector[Ticket](db).Where(C("Role").In(UserActive, UserBlocked)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `ticket` WHERE `role` IN (?,?);",
+				Args: []any{"active", "blocked"},
+			},
+		},
+		{
+			// 已经是数据库里面的值，原样使用
+			name:    "raw value",
+			builder: NewSelector[Ticket](db).Where(C("Role").EQ("active")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `ticket` WHERE `role` = ?;",
+				Args: []any{"active"},
+			},
+		},
+		{
+			name:    "pointer",
+			builder: NewSelector[Ticket](db).Where(C("Status").NEQ(UserBlocked)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `ticket` WHERE `status` != ?;",
+				Args: []any{int64(2)},
+			},
+		},
+		{
+			name:    "unknown",
+			builder: NewSelector[Ticket](db).Where(C("Role").EQ(UserStatus(7))),
+			wantErr: &UnknownEnumError{Type: reflect.TypeOf(UserStatus(0)), Value: UserStatus(7)},
+		},
+		{
+			// 加密的结果每次都不一样，永远匹配不上
+			name:    "aes",
+			builder: NewSelector[Profile](db).Where(C("Secret").EQ("password")),
+			wantErr: errs.NewErrUnstablePredicate("Secret"),
+		},
+		{
+			name:    "json",
+			builder: NewSelector[Profile](db).Where(C("Tags").In([]string{"a"})),
+			wantErr: errs.NewErrUnstablePredicate("Tags"),
+		},
+		{
+			// 已经是数据库里面的值，原样使用
+			name:    "json raw value",
+			builder: NewSelector[Profile](db).Where(C("Tags").EQ(`["a"]`)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `profile` WHERE `tags` = ?;",
+				Args: []any{`["a"]`},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
 func TestSelector_GET(t *testing.T) {
 	mockDb, mock, err := sqlmock.New()
 	defer mockDb.Close()
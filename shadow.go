@@ -0,0 +1,121 @@
+package orm
+
+import (
+	"context"
+	"orm/internal/errs"
+	"strings"
+)
+
+type shadowKey struct{}
+
+// shadowPrefix 是影子表的前缀，影子表和正式表结构一样
+const shadowPrefix = "shadow_"
+
+// shadow 是压测流量的去向，table 为 true 的时候使用影子表，db 不为 nil 的时候使用影子库，
+// 两个都设置的时候在影子库里面使用影子表
+type shadow struct {
+	table bool
+	db    *DB
+}
+
+// WithShadow 标记 ctx 上执行的语句是压测流量。
+// 压测流量只会发到影子表或者影子库，DB 没有配置的时候直接返回 errs.ErrShadowDisabled
+func WithShadow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shadowKey{}, true)
+}
+
+// IsShadow 判断 ctx 上的语句是不是压测流量
+func IsShadow(ctx context.Context) bool {
+	val, _ := ctx.Value(shadowKey{}).(bool)
+	return val
+}
+
+// DBWithShadowTable 压测流量使用 shadow_ 开头的影子表，包括 JOIN 和子查询里面的表。
+// 影子表模式下不能执行原生查询，也不能使用 From 指定的表名，因为没法保证它们不会访问正式表
+func DBWithShadowTable() DBOptions {
+	return func(db *DB) {
+		if db.shadow == nil {
+			db.shadow = &shadow{}
+		}
+		db.shadow.table = true
+	}
+}
+
+// DBWithShadowDB 压测流量发到 shadowDB 执行，使用 shadowDB 的中间件，
+// 在 ctx 上开启的事务也会在 shadowDB 上开启。shadowDB 要和当前的 DB 使用同一种方言
+func DBWithShadowDB(shadowDB *DB) DBOptions {
+	return func(db *DB) {
+		if db.shadow == nil {
+			db.shadow = &shadow{}
+		}
+		db.shadow.db = shadowDB
+	}
+}
+
+// shadowTable 在影子表模式下返回影子表的名字
+func (b *builder) shadowTable(name string) string {
+	if b.shadow != nil && b.shadow.table && IsShadow(b.context()) {
+		return shadowPrefix + name
+	}
+	return name
+}
+
+// rewriteShadow 把语句里面的表都换成影子表，放在所有 Rewriter 的最后面，
+// rawTable 是 From 指定的表名
+func (b *builder) rewriteShadow(stmt *Statement, rawTable string) error {
+	if b.shadow == nil || !b.shadow.table || !IsShadow(b.context()) {
+		return nil
+	}
+	if rawTable != "" {
+		return errs.ErrShadowRaw
+	}
+	return b.renameShadow(stmt)
+}
+
+// renameShadow 把语句里面还没有换成影子表的表换成影子表，可以重复调用
+func (b *builder) renameShadow(stmt *Statement) error {
+	if b.shadow == nil || !b.shadow.table || !IsShadow(b.context()) {
+		return nil
+	}
+	tables, err := stmt.Tables()
+	if err != nil {
+		return err
+	}
+	for _, tbl := range tables {
+		if name := stmt.TableName(tbl); !strings.HasPrefix(name, shadowPrefix) {
+			stmt.RenameTable(tbl, shadowPrefix+name)
+		}
+	}
+	return nil
+}
+
+// route 决定压测流量在哪里执行，返回执行语句的 Session 和它的 core。
+// raw 为 true 的时候是原生查询，影子表模式下拒绝执行
+func (c core) route(ctx context.Context, sess Session, raw bool) (Session, core, error) {
+	if !IsShadow(ctx) {
+		return sess, c, nil
+	}
+	if c.shadow == nil {
+		return nil, c, errs.ErrShadowDisabled
+	}
+	if sdb := c.shadow.db; sdb != nil {
+		switch s := sess.(type) {
+		case *DB:
+			if s != sdb {
+				sess = sdb
+			}
+		case *Tx:
+			if s.db != sdb {
+				return nil, c, errs.ErrShadowTx
+			}
+		}
+		// 使用影子库的中间件和方言，模型还是原来的
+		sc := sdb.core
+		sc.r, sc.Model = c.r, c.Model
+		return sess, sc, nil
+	}
+	if raw {
+		return nil, c, errs.ErrShadowRaw
+	}
+	return sess, c, nil
+}
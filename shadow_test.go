@@ -0,0 +1,178 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestShadowTable(t *testing.T) {
+	db, err := Open("sqlite3", "file:shadow_table.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite), DBWithShadowTable())
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(Order{}.CreateSQL())
+	require.NoError(t, err)
+	_, err = db.db.Exec("INSERT INTO `order` VALUES (1, 1, 'real')")
+	require.NoError(t, err)
+
+	ctx := WithShadow(context.Background())
+	err = NewCreateTable[Order](db).IfNotExists().Exec(ctx).Err()
+	require.NoError(t, err)
+
+	err = NewInserter[Order](db).Values(&Order{Id: 1, UserId: 1, Status: "new"}).Exec(ctx).Err()
+	require.NoError(t, err)
+	err = NewUpdater[Order](db).Set(Assign("Status", "paid")).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+	o, err := NewSelector[Order](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "paid", o.Status)
+
+	// 正式表没有变化
+	o, err = NewSelector[Order](db).Where(C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "real", o.Status)
+
+	err = NewDeleter[Order](db).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+	_, err = NewSelector[Order](db).Where(C("Id").EQ(1)).Get(ctx)
+	assert.Equal(t, ErrNoRows, err)
+	_, err = NewSelector[Order](db).Where(C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+
+	// JOIN 和子查询里面的表也会被替换
+	od := TableOf(&Order{}).As("o")
+	p := TableOf(&Post{}).As("p")
+	q, err := bindContext(ctx, NewSelector[Order](db).From(od.Join(p).On(od.C("UserId").EQ(p.C("Id")))).
+		Where(Exist(NewSelector[Post](db).AsSubQuery()))).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (`shadow_order` AS `o` JOIN `shadow_post` AS `p` ON `o`.`user_id` = `p`.`id`) "+
+		"WHERE  EXIST (SELECT * FROM `shadow_post`);", q.SQL)
+
+	// 没法确认表名的语句不允许执行
+	_, err = RawQuery[Order](db, "SELECT * FROM `order`").Get(ctx)
+	assert.Equal(t, errs.ErrShadowRaw, err)
+	err = NewDeleter[Order](db).From("`order`").Where(C("Id").EQ(1)).Exec(ctx).Err()
+	assert.Equal(t, errs.ErrShadowRaw, err)
+	err = NewInserter[Order](db).From("`order`").Values(&Order{Id: 2}).Exec(ctx).Err()
+	assert.Equal(t, errs.ErrShadowRaw, err)
+}
+
+func TestShadowTable_Middleware(t *testing.T) {
+	var query string
+	// 中间件在 Rewriter 之后替换了表，生成 SQL 的时候还是要用影子表
+	m := func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			stmt, err := qc.Statement()
+			if err != nil {
+				return &QueryResult{Err: err}
+			}
+			od, p := TableOf(&Order{}), TableOf(&Post{})
+			stmt.Table = od.Join(p).On(od.C("UserId").EQ(p.C("Id")))
+			q, err := qc.Query()
+			if err != nil {
+				return &QueryResult{Err: err}
+			}
+			query = q.SQL
+			return &QueryResult{Err: ErrNoRows}
+		}
+	}
+	db, err := Open("sqlite3", "file:shadow_table_middleware.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite), DBWithShadowTable(), DBWithMiddleware(m))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = NewSelector[Order](db).Get(WithShadow(context.Background()))
+	assert.Equal(t, ErrNoRows, err)
+	assert.Equal(t, "SELECT * FROM (`shadow_order` JOIN `shadow_post` ON `user_id` = `id`);", query)
+
+	_, err = NewSelector[Order](db).Get(context.Background())
+	assert.Equal(t, ErrNoRows, err)
+	assert.Equal(t, "SELECT * FROM (`order` JOIN `post` ON `user_id` = `id`);", query)
+}
+
+func TestShadowDB(t *testing.T) {
+	shadowDB, err := Open("sqlite3", "file:shadow_db_shadow.db?cache=shared&mode=memory")
+	require.NoError(t, err)
+	defer shadowDB.Close()
+	db, err := Open("sqlite3", "file:shadow_db_real.db?cache=shared&mode=memory", DBWithShadowDB(shadowDB))
+	require.NoError(t, err)
+	defer db.Close()
+	for i, d := range []*DB{db, shadowDB} {
+		_, err = d.db.Exec(Order{}.CreateSQL())
+		require.NoError(t, err)
+		_, err = d.db.Exec("INSERT INTO `order` VALUES (1, 1, ?)", []string{"real", "shadow"}[i])
+		require.NoError(t, err)
+	}
+
+	ctx := WithShadow(context.Background())
+	o, err := NewSelector[Order](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "shadow", o.Status)
+	o, err = RawQuery[Order](db, "SELECT * FROM `order` WHERE `id` = ?", 1).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "shadow", o.Status)
+
+	// 事务开启在影子库上
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return NewUpdater[Order](tx).Set(Assign("Status", "paid")).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	}, nil)
+	require.NoError(t, err)
+	o, err = NewSelector[Order](shadowDB).Where(C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "paid", o.Status)
+	o, err = NewSelector[Order](db).Where(C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "real", o.Status)
+
+	// 正式库上的事务不能执行压测流量
+	tx, err := db.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	err = NewDeleter[Order](tx).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	assert.Equal(t, errs.ErrShadowTx, err)
+}
+
+func TestShadow_Disabled(t *testing.T) {
+	db := memoryDB(t)
+	ctx := WithShadow(context.Background())
+	_, err := NewSelector[Order](db).Get(ctx)
+	assert.Equal(t, errs.ErrShadowDisabled, err)
+	err = NewDeleter[Order](db).Exec(ctx).Err()
+	assert.Equal(t, errs.ErrShadowDisabled, err)
+	_, err = Scan[Order](ctx, NewSelector[Order](db))
+	assert.Equal(t, errs.ErrShadowDisabled, err)
+	_, err = db.BeginTx(ctx, nil)
+	assert.Equal(t, errs.ErrShadowDisabled, err)
+}
+
+func TestShadowDB_Explain(t *testing.T) {
+	var plan *Plan
+	var explainErr error
+	// 压测流量使用影子库的中间件
+	shadowDB, err := Open("sqlite3", "file:shadow_explain_shadow.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite), DBWithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, qc *QueryContext) *QueryResult {
+				plan, explainErr = qc.Explain(ctx)
+				return next(ctx, qc)
+			}
+		}))
+	require.NoError(t, err)
+	defer shadowDB.Close()
+	db, err := Open("sqlite3", "file:shadow_explain_real.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite), DBWithShadowDB(shadowDB))
+	require.NoError(t, err)
+	defer db.Close()
+	// 只有影子库里面有这张表，在正式库上 EXPLAIN 会失败
+	_, err = shadowDB.db.Exec(Order{}.CreateSQL())
+	require.NoError(t, err)
+
+	_, err = NewSelector[Order](db).Where(C("Id").EQ(1)).Get(WithShadow(context.Background()))
+	assert.Equal(t, ErrNoRows, err)
+	require.NoError(t, explainErr)
+	assert.Equal(t, "SELECT * FROM `order` WHERE `id` = ?;", plan.SQL)
+	assert.NotEmpty(t, plan.Rows)
+}
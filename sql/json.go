@@ -23,10 +23,10 @@ func (j *JsonColum[T]) Scan(src any) error {
 	default:
 		return fmt.Errorf("ekit：JsonColumn.Scan 不支持 src 类型 %v", src)
 	}
-	err := json.Unmarshal(bs, &j.Val)
-	if err == nil {
-		j.Valid = true
+	if err := json.Unmarshal(bs, &j.Val); err != nil {
+		return err
 	}
+	j.Valid = true
 	return nil
 }
 
@@ -54,6 +54,13 @@ func TestJsonColumn_Scan(t *testing.T) {
 	}
 }
 
+func TestJsonColumn_ScanInvalid(t *testing.T) {
+	js := &JsonColum[User]{}
+	err := js.Scan(`{"Name":`)
+	assert.Error(t, err)
+	assert.False(t, js.Valid)
+}
+
 func TestJsonColumn_ScanTypes(t *testing.T) {
 	jsSlice := JsonColum[[]string]{}
 	err := jsSlice.Scan(`["a", "b", "c"]`)
@@ -0,0 +1,198 @@
+package orm
+
+import (
+	"context"
+	"orm/model"
+)
+
+// Statement 是还没有生成 SQL 的语句，Where 是调用方指定的条件和游标的条件，
+// Scope 和租户的条件在 Scopes 里面，生成 SQL 的时候放在 Where 后面。
+// 中间件可以通过 QueryContext.Statement 读取，在调用 QueryContext.Query 之前的修改都会生效
+type Statement struct {
+	// Type 是 SELECT、INSERT、UPDATE 或者 DELETE
+	Type  string
+	Model *model.Model
+	// Table 为 nil 的时候是 Model 对应的表
+	Table TableReference
+	// Columns 是 SELECT 的列，为空的时候是 *
+	Columns []Selectable
+	// Assigns 是 UPDATE 要更新的列，为空的时候更新所有的列
+	Assigns []Assignable
+	Where   []Predicate
+	// Scopes 是 Scope 和多租户隔离自动加上的条件
+	Scopes  []Predicate
+	OrderBy []OrderBy
+	Limit   int
+	Offset  int
+	// Hints 是优化器提示
+	Hints []string
+
+	r       model.Registry
+	renames map[string]string
+}
+
+// Rewriter 在生成 SQL 之前修改语句，比如加上条件、替换表名或者加上优化器提示。
+// Unscoped 不会跳过 Rewriter，子查询和 INSERT ... SELECT 里面的查询也会经过 Rewriter
+type Rewriter func(ctx context.Context, stmt *Statement) error
+
+// DBWithRewriter 按照顺序使用这些 Rewriter
+func DBWithRewriter(rs ...Rewriter) DBOptions {
+	return func(db *DB) {
+		db.rewriters = append(db.rewriters, rs...)
+	}
+}
+
+// RenameTable 把语句里面的表 from 换成 to，包括 JOIN 里面的表，不包括子查询，
+// 子查询有自己的 Statement
+func (s *Statement) RenameTable(from, to string) {
+	if s.renames == nil {
+		s.renames = make(map[string]string, 2)
+	}
+	s.renames[from] = to
+}
+
+// predicates 返回生成 SQL 使用的所有条件
+func (s *Statement) predicates() []Predicate {
+	if len(s.Scopes) == 0 {
+		return s.Where
+	}
+	res := make([]Predicate, 0, len(s.Where)+len(s.Scopes))
+	res = append(res, s.Where...)
+	return append(res, s.Scopes...)
+}
+
+// TableName 返回 name 替换之后的表名
+func (s *Statement) TableName(name string) string {
+	if to, ok := s.renames[name]; ok {
+		return to
+	}
+	return name
+}
+
+// Tables 返回语句直接用到的表，是替换之前的表名，不包括子查询里面的表
+func (s *Statement) Tables() ([]string, error) {
+	res := make([]string, 0, 2)
+	var walk func(table TableReference) error
+	walk = func(table TableReference) error {
+		var name string
+		switch t := table.(type) {
+		case nil:
+			name = s.Model.TableName
+		case Table:
+			m, err := s.r.Get(t.entity)
+			if err != nil {
+				return err
+			}
+			name = m.TableName
+		case Join:
+			if err := walk(t.left); err != nil {
+				return err
+			}
+			return walk(t.right)
+		default:
+			return nil
+		}
+		res = appendTable(res, name)
+		return nil
+	}
+	return res, walk(s.Table)
+}
+
+// allTables 把语句用到的表按照替换之后的名字加到 res 里面，
+// 子查询用 ctx 构造自己的 Statement，所以也会经过 Rewriter 和影子表的替换
+func (s *Statement) allTables(ctx context.Context, res []string) ([]string, error) {
+	tbls, err := s.Tables()
+	if err != nil {
+		return nil, err
+	}
+	for _, tbl := range tbls {
+		res = appendTable(res, s.TableName(tbl))
+	}
+	subs := subQueries(s.Table, nil)
+	for _, p := range s.predicates() {
+		subs = subQueries(p, subs)
+	}
+	for _, sub := range subs {
+		sb, ok := bindContext(ctx, sub.s).(statementBuilder)
+		if !ok {
+			continue
+		}
+		stmt, err := sb.statement()
+		if err != nil {
+			return nil, err
+		}
+		if res, err = stmt.allTables(ctx, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// subQueries 找出 exp 里面的子查询，exp 是 TableReference 或者 Expression
+func subQueries(exp any, res []SubQuery) []SubQuery {
+	switch e := exp.(type) {
+	case SubQuery:
+		res = append(res, e)
+	case SubqueryExpr:
+		res = append(res, e.s)
+	case Predicate:
+		res = subQueries(e.left, res)
+		res = subQueries(e.right, res)
+	case Join:
+		res = subQueries(e.left, res)
+		res = subQueries(e.right, res)
+		for _, p := range e.on {
+			res = subQueries(p, res)
+		}
+	}
+	return res
+}
+
+func appendTable(res []string, name string) []string {
+	for _, n := range res {
+		if n == name {
+			return res
+		}
+	}
+	return append(res, name)
+}
+
+// statementBuilder 是可以先得到 Statement 再生成 SQL 的 QueryBuilder，
+// 它们的 Build 就是 statement 加上 render
+type statementBuilder interface {
+	statement() (*Statement, error)
+	render(stmt *Statement) (*Query, error)
+}
+
+func (b *builder) newStatement(typ string) *Statement {
+	return &Statement{Type: typ, Model: b.Model, Hints: b.hints, r: b.r}
+}
+
+// rewrite 使用 DBWithRewriter 注册的 Rewriter，最后处理压测流量的影子表，
+// rawTable 是 From 指定的表名
+func (b *builder) rewrite(stmt *Statement, rawTable string) error {
+	for _, r := range b.rewriters {
+		if err := r(b.context(), stmt); err != nil {
+			return err
+		}
+	}
+	return b.rewriteShadow(stmt, rawTable)
+}
+
+// apply 使用 stmt 里面和具体语句无关的部分。
+// 中间件可能在 statement 之后替换了表，所以生成 SQL 之前还要再换一次影子表
+func (b *builder) apply(stmt *Statement) error {
+	if err := b.renameShadow(stmt); err != nil {
+		return err
+	}
+	b.Model, b.hints, b.renames = stmt.Model, stmt.Hints, stmt.renames
+	return nil
+}
+
+// tableName 返回替换之后的表名
+func (b *builder) tableName(name string) string {
+	if to, ok := b.renames[name]; ok {
+		return to
+	}
+	return name
+}
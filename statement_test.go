@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestRewriter(t *testing.T) {
+	db := memoryDB(t, DBWithRewriter(func(ctx context.Context, stmt *Statement) error {
+		tables, err := stmt.Tables()
+		if err != nil {
+			return err
+		}
+		for _, tbl := range tables {
+			stmt.RenameTable(tbl, "shadow_"+tbl)
+		}
+		if stmt.Type == "SELECT" {
+			stmt.Hints = append(stmt.Hints, "MAX_EXECUTION_TIME(100)")
+			stmt.Where = append(stmt.Where, C("Id").GT(0))
+		}
+		return nil
+	}))
+	o := TableOf(&Order{}).As("o")
+	p := TableOf(&Post{}).As("p")
+
+	testCases := []struct {
+		name     string
+		b        QueryBuilder
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "select",
+			b:        NewSelector[Order](db).Where(C("Status").EQ("paid")),
+			wantSQL:  "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM `shadow_order` WHERE (`status` = ?) AND (`id` > ?);",
+			wantArgs: []any{"paid", 0},
+		},
+		{
+			name:     "select join",
+			b:        NewSelector[Order](db).From(o.Join(p).On(o.C("UserId").EQ(p.C("Id")))),
+			wantSQL:  "SELECT /*+ MAX_EXECUTION_TIME(100) */ * FROM (`shadow_order` AS `o` JOIN `shadow_post` AS `p` ON `o`.`user_id` = `p`.`id`) WHERE `id` > ?;",
+			wantArgs: []any{0},
+		},
+		{
+			name: "sub query",
+			b: NewSelector[Order](db).Select(C("Id")).
+				Where(Exist(NewSelector[Post](db).Select(C("Id")).AsSubQuery())),
+			wantSQL: "SELECT /*+ MAX_EXECUTION_TIME(100) */ `id` FROM `shadow_order` WHERE ( EXIST " +
+				"(SELECT /*+ MAX_EXECUTION_TIME(100) */ `id` FROM `shadow_post` WHERE `id` > ?)) AND (`id` > ?);",
+			wantArgs: []any{0, 0},
+		},
+		{
+			name:     "insert",
+			b:        NewInserter[Order](db).Values(&Order{Id: 1}),
+			wantSQL:  "INSERT INTO `shadow_order`(`id`,`user_id`,`status`) VALUES (?,?,?);",
+			wantArgs: []any{int64(1), int64(0), ""},
+		},
+		{
+			name:     "update",
+			b:        NewUpdater[Order](db).Set(Assign("Status", "paid")).Where(C("Id").EQ(1)),
+			wantSQL:  "UPDATE `shadow_order` SET `status`=? WHERE `id` = ?;",
+			wantArgs: []any{"paid", 1},
+		},
+		{
+			name:     "delete",
+			b:        NewDeleter[Order](db).Where(C("Id").EQ(1)),
+			wantSQL:  "DELETE FROM `shadow_order` WHERE `id` = ?;",
+			wantArgs: []any{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.b.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSQL, q.SQL)
+			assert.Equal(t, tc.wantArgs, q.Args)
+		})
+	}
+}
+
+func TestQueryContext_Statement(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	var stmt *Statement
+	db, err := OpenDB(mockDB, DBWithTenant("TenantId", tenantOf), DBWithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, qc *QueryContext) *QueryResult {
+			if qc.Type == "RAW" {
+				_, err := qc.Statement()
+				assert.Equal(t, errs.ErrNoStatement, err)
+				return next(ctx, qc)
+			}
+			stmt, err = qc.Statement()
+			if err != nil {
+				return &QueryResult{Err: err}
+			}
+			// 在生成 SQL 之前修改
+			stmt.Limit = 10
+			return next(ctx, qc)
+		}
+	}))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT * FROM `post` WHERE (`title` = ?) AND (`tenant_id` = ?) LIMIT ?;").
+		WithArgs("orm", int64(7), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+	_, err = NewSelector[Post](db).Where(C("Title").EQ("orm")).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT", stmt.Type)
+	assert.Equal(t, "post", stmt.Model.TableName)
+	assert.Equal(t, []Predicate{C("Title").EQ("orm")}, stmt.Where)
+	assert.Equal(t, []Predicate{C("TenantId").EQ(int64(7))}, stmt.Scopes)
+	tables, err := stmt.Tables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"post"}, tables)
+
+	mock.ExpectExec("DELETE FROM `post`;").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = RawQuery[Post](db, "DELETE FROM `post`;").Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
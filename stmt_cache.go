@@ -0,0 +1,114 @@
+package orm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+)
+
+// StmtCacheStats 预编译语句缓存的命中统计
+type StmtCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// stmtCache 以 SQL 为键，LRU 淘汰的 *sql.Stmt 缓存
+type stmtCache struct {
+	capacity int
+	mutex    sync.Mutex
+	lru      *list.List
+	stmts    map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type stmtEntry struct {
+	query string
+	stmt  *sql.Stmt
+	// refs 正在使用这个 stmt 的调用数，被淘汰的 stmt 要等到没人用了才关闭
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		lru:      list.New(),
+		stmts:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// acquire 拿到 query 对应的 stmt，用完之后必须调用 release
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) (*stmtEntry, error) {
+	c.mutex.Lock()
+	if elem, ok := c.stmts[query]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mutex.Unlock()
+		atomic.AddUint64(&c.hits, 1)
+		return entry, nil
+	}
+	c.mutex.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	// 预编译放在锁外面，避免一条慢的 Prepare 阻塞其它查询
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, ok := c.stmts[query]; ok {
+		// 并发情况下别人已经放进去了，用别人的
+		_ = stmt.Close()
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		return entry, nil
+	}
+	entry := &stmtEntry{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.evict(c.lru.Back())
+	}
+	return entry, nil
+}
+
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// evict 必须在持有锁的情况下调用
+func (c *stmtCache) evict(elem *list.Element) {
+	entry := elem.Value.(*stmtEntry)
+	c.lru.Remove(elem)
+	delete(c.stmts, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+func (c *stmtCache) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	return StmtCacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+	}
+}
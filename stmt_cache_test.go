@@ -0,0 +1,76 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_StmtCache(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithStmtCache(1))
+	require.NoError(t, err)
+
+	first := mock.ExpectPrepare("SELECT \\* FROM `test_model` WHERE `id` = \\?;").WillBeClosed()
+	mockRows := mock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tom", 18, "Jerry")
+	first.ExpectQuery().WithArgs(1).WillReturnRows(mockRows)
+	mockRows = mock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(2, "Tim", 19, "Jerry")
+	first.ExpectQuery().WithArgs(2).WillReturnRows(mockRows)
+	second := mock.ExpectPrepare("INSERT INTO `test_model`").WillBeClosed()
+	second.ExpectExec().WillReturnResult(sqlmock.NewResult(3, 1))
+
+	ctx := context.Background()
+	res, err := NewSelector[TestModel](db).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Id)
+	res, err = NewSelector[TestModel](db).Where(C("Id").EQ(2)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), res.Id)
+	assert.Equal(t, StmtCacheStats{Hits: 1, Misses: 1}, db.StmtCacheStats())
+
+	// 容量只有 1，插入语句会把查询语句挤出去
+	affected, err := NewInserter[TestModel](db).Values(&TestModel{Id: 3}).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.Equal(t, StmtCacheStats{Hits: 1, Misses: 2}, db.StmtCacheStats())
+
+	mock.ExpectClose()
+	require.NoError(t, db.Close())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTx_StmtCache(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithStmtCache(8))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	// 第一次是在连接池上预编译，第二次是 StmtContext 在事务的连接上预编译
+	mock.ExpectPrepare("INSERT INTO `test_model`")
+	prepare := mock.ExpectPrepare("INSERT INTO `test_model`")
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		for i := 1; i <= 2; i++ {
+			res := NewInserter[TestModel](tx).Values(&TestModel{Id: int64(i)}).Exec(ctx)
+			if res.Err() != nil {
+				return res.Err()
+			}
+		}
+		return nil
+	}, &sql.TxOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, StmtCacheStats{Hits: 1, Misses: 1}, db.StmtCacheStats())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,151 @@
+package orm
+
+import (
+	"orm/internal/valuer"
+	"orm/model"
+	"reflect"
+	"sync"
+)
+
+// Change 是跟踪的实体里面一个字段的变化
+type Change struct {
+	Field  string
+	Column string
+	Old    any
+	New    any
+}
+
+// tracker 保存查询出来的实体的快照，键是实体的指针
+type tracker struct {
+	mu        sync.RWMutex
+	snapshots map[any]map[string]any
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		snapshots: make(map[any]map[string]any),
+	}
+}
+
+// Tracked 返回一个开启了脏数据跟踪的 DB，它和 db 共用连接池和配置。
+// 通过它查询出来的实体都会保存一份快照，Updater.Update 的时候只更新和快照不一样的列。
+// 快照只有在返回的 DB 不再使用的时候才会被回收，所以一般在一个业务流程里面创建一个
+func (db *DB) Tracked() *DB {
+	res := *db
+	res.tracker = newTracker()
+	return &res
+}
+
+func (t *tracker) snapshot(m *model.Model, entity any, val valuer.Value) error {
+	snapshot := make(map[string]any, len(m.FieldArr))
+	for _, fd := range m.FieldArr {
+		v, err := trackedValue(fd, entity, val)
+		if err != nil {
+			return err
+		}
+		snapshot[fd.GoName] = clone(v)
+	}
+	t.mu.Lock()
+	t.snapshots[entity] = snapshot
+	t.mu.Unlock()
+	return nil
+}
+
+// changes 比较实体和快照，第二个返回值表示实体有没有被跟踪
+func (t *tracker) changes(m *model.Model, entity any, val valuer.Value) ([]Change, bool, error) {
+	t.mu.RLock()
+	snapshot, ok := t.snapshots[entity]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	var res []Change
+	for _, fd := range m.FieldArr {
+		v, err := trackedValue(fd, entity, val)
+		if err != nil {
+			return nil, true, err
+		}
+		old := snapshot[fd.GoName]
+		if !reflect.DeepEqual(old, v) {
+			res = append(res, Change{Field: fd.GoName, Column: fd.ColName, Old: old, New: v})
+		}
+	}
+	return res, true, nil
+}
+
+// trackedValue 有 Converter 的字段比较 Go 的值，因为转换的结果不一定是稳定的，比如加密
+func trackedValue(fd *model.Field, entity any, val valuer.Value) (any, error) {
+	if fd.Converter == nil {
+		return val.Field(fd.GoName)
+	}
+	return reflect.ValueOf(entity).Elem().FieldByName(fd.GoName).Interface(), nil
+}
+
+// clone 指针、slice 和 map 指向的数据可能会被原地修改，比如 *p.Age = 3，所以深拷贝一份
+func clone(v any) any {
+	if v == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(v), map[uintptr]reflect.Value{}).Interface()
+}
+
+// deepCopy seen 记录已经复制过的指针，避免循环引用
+func deepCopy(v reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		if res, ok := seen[v.Pointer()]; ok {
+			return res
+		}
+		res := reflect.New(v.Type().Elem())
+		seen[v.Pointer()] = res
+		res.Elem().Set(deepCopy(v.Elem(), seen))
+		return res
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return res
+	case reflect.Array:
+		res := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+		return res
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			res.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+		return res
+	case reflect.Struct:
+		// 没有导出的字段只能原样复制，比如 time.Time 里面的 *Location
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := res.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i), seen))
+			}
+		}
+		return res
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(deepCopy(v.Elem(), seen))
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+func TestDB_Tracked(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	tdb := db.Tracked()
+	ctx := context.Background()
+
+	mockRows := sqlmock.NewRows([]string{"id", "first_name", "age", "last_name"})
+	mockRows.AddRow(1, "Tom", 18, "Jerry")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(mockRows)
+	entity, err := NewSelector[TestModel](tdb).Where(C("Id").EQ(1)).Get(ctx)
+	require.NoError(t, err)
+
+	// 没有变化的时候什么都不做
+	affected, err := NewUpdater[TestModel](tdb).Update(entity).Where(C("Id").EQ(1)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+	_, err = NewUpdater[TestModel](tdb).Update(entity).Build()
+	assert.Equal(t, errs.ErrNoUpdatedColumns, err)
+
+	entity.FirstName = "Tim"
+	entity.Age = 19
+	changes, err := NewUpdater[TestModel](tdb).Update(entity).Changes()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Field: "FirstName", Column: "first_name", Old: "Tom", New: "Tim"},
+		{Field: "Age", Column: "age", Old: int8(18), New: int8(19)},
+	}, changes)
+
+	mock.ExpectExec("UPDATE `test_model` SET `first_name`=\\?,`age`=\\? WHERE `id` = \\?;").
+		WithArgs("Tim", 19, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	affected, err = NewUpdater[TestModel](tdb).Update(entity).Where(C("Id").EQ(1)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	// 更新之后以新的值为准
+	entity.LastName = sql.NullString{String: "Mouse", Valid: true}
+	mock.ExpectExec("UPDATE `test_model` SET `last_name`=\\? WHERE `id` = \\?;").
+		WithArgs(sql.NullString{String: "Mouse", Valid: true}, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	err = NewUpdater[TestModel](tdb).Update(entity).Where(C("Id").EQ(1)).Exec(ctx).Err()
+	require.NoError(t, err)
+
+	// Set 指定了列的时候不看快照
+	q, err := NewUpdater[TestModel](tdb).Update(entity).Set(C("Age")).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `test_model` SET `age`=?;", q.SQL)
+
+	// 没有被跟踪的实体更新所有的列
+	q, err = NewUpdater[TestModel](tdb).Update(&TestModel{Id: 2}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `test_model` SET `id`=?,`first_name`=?,`age`=?,`last_name`=?;", q.SQL)
+	_, err = NewUpdater[TestModel](tdb).Update(&TestModel{Id: 2}).Changes()
+	assert.Equal(t, errs.ErrEntityNotTracked, err)
+
+	// 原本的 DB 不跟踪
+	_, err = NewUpdater[TestModel](db).Update(entity).Changes()
+	assert.Equal(t, errs.ErrEntityNotTracked, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDB_Tracked_InPlace(t *testing.T) {
+	type Member struct {
+		Id    int64
+		Age   *int
+		Tags  []string         `orm:"serializer=json"`
+		Attrs map[string][]int `orm:"serializer=json"`
+	}
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+	tdb := db.Tracked()
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "age", "tags", "attrs"}).
+		AddRow(1, 18, `["a"]`, `{"k":[1]}`))
+	entity, err := NewSelector[Member](tdb).Where(C("Id").EQ(1)).Get(context.Background())
+	require.NoError(t, err)
+
+	// 原地修改指针、slice 和 map 里面的数据
+	*entity.Age = 3
+	entity.Tags[0] = "b"
+	entity.Attrs["k"][0] = 2
+	changes, err := NewUpdater[Member](tdb).Update(entity).Changes()
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, 18, *changes[0].Old.(*int))
+	assert.Equal(t, 3, *changes[0].New.(*int))
+	assert.Equal(t, []string{"a"}, changes[1].Old)
+	assert.Equal(t, map[string][]int{"k": {1}}, changes[2].Old)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTracker_Clone(t *testing.T) {
+	type node struct {
+		Val  int
+		Next *node
+	}
+	n := &node{Val: 1}
+	n.Next = n
+	res := clone(n).(*node)
+	assert.NotSame(t, n, res)
+	// 循环引用也指向复制出来的节点
+	assert.Same(t, res, res.Next)
+	n.Val = 2
+	assert.Equal(t, 1, res.Val)
+
+	arr := [1]*int{new(int)}
+	cp := clone(arr).([1]*int)
+	*arr[0] = 1
+	assert.Equal(t, 0, *cp[0])
+	assert.Nil(t, clone(nil))
+	assert.Equal(t, []int(nil), clone([]int(nil)))
+}
@@ -8,6 +8,8 @@ import (
 type Tx struct {
 	tx *sql.Tx
 	db *DB
+	// main 是开启事务的 DB，压测流量的事务开启在影子库上，这时候 db 是影子库
+	main *DB
 }
 
 var (
@@ -16,11 +18,37 @@ var (
 )
 
 func (t *Tx) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return t.tx.QueryContext(ctx, query, args...)
+	if t.db.stmtCache == nil {
+		return t.tx.QueryContext(ctx, query, args...)
+	}
+	stmt, err := t.stmtContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
 }
 
 func (t *Tx) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return t.tx.ExecContext(ctx, query, args...)
+	if t.db.stmtCache == nil {
+		return t.tx.ExecContext(ctx, query, args...)
+	}
+	stmt, err := t.stmtContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// stmtContext 把 DB 上缓存的 stmt 绑定到事务上。
+// 事务里的 stmt 会在提交或者回滚的时候自动关闭，
+// 而且它依赖的 stmt 即便被淘汰了，也要等它关闭之后才会真的关闭
+func (t *Tx) stmtContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	entry, err := t.db.stmtCache.acquire(ctx, t.db.db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer t.db.stmtCache.release(entry)
+	return t.tx.StmtContext(ctx, entry.stmt), nil
 }
 
 func (t *Tx) Commit() error {
@@ -32,7 +60,7 @@ func (t *Tx) Rollback() error {
 }
 
 func (t *Tx) getCore() core {
-	return t.db.getCore()
+	return t.main.getCore()
 }
 
 func (t *Tx) RollBackIfNotCommit() error {
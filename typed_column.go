@@ -0,0 +1,85 @@
+package orm
+
+// TypedColumn 是带类型的列，比较的时候参数的类型由编译器检查。
+// 一般不直接使用，而是使用 gen 生成的 XXXColumns
+type TypedColumn[T any] struct {
+	name string
+}
+
+// TypedC 创建类型为 T 的列，name 是字段名
+func TypedC[T any](name string) TypedColumn[T] {
+	return TypedColumn[T]{name: name}
+}
+
+// Column 返回对应的 Column，可以用在 Select、GroupBy 里面
+func (c TypedColumn[T]) Column() Column {
+	return C(c.name)
+}
+
+func (c TypedColumn[T]) As(alias string) Column {
+	return C(c.name).As(alias)
+}
+
+func (c TypedColumn[T]) EQ(val T) Predicate {
+	return C(c.name).EQ(val)
+}
+
+func (c TypedColumn[T]) NEQ(val T) Predicate {
+	return C(c.name).NEQ(val)
+}
+
+func (c TypedColumn[T]) LT(val T) Predicate {
+	return C(c.name).LT(val)
+}
+
+func (c TypedColumn[T]) LTEQ(val T) Predicate {
+	return C(c.name).LTEQ(val)
+}
+
+func (c TypedColumn[T]) GT(val T) Predicate {
+	return C(c.name).GT(val)
+}
+
+func (c TypedColumn[T]) GTEQ(val T) Predicate {
+	return C(c.name).GTEQ(val)
+}
+
+func (c TypedColumn[T]) In(vals ...T) Predicate {
+	args := make([]any, 0, len(vals))
+	for _, val := range vals {
+		args = append(args, val)
+	}
+	return C(c.name).In(args...)
+}
+
+func (c TypedColumn[T]) Like(pattern string) Predicate {
+	return C(c.name).Like(pattern)
+}
+
+func (c TypedColumn[T]) Asc() OrderBy {
+	return Asc(c.name)
+}
+
+func (c TypedColumn[T]) Desc() OrderBy {
+	return Desc(c.name)
+}
+
+func (c TypedColumn[T]) Avg() Aggregate {
+	return Avg(c.name)
+}
+
+func (c TypedColumn[T]) Max() Aggregate {
+	return Max(c.name)
+}
+
+func (c TypedColumn[T]) Min() Aggregate {
+	return Min(c.name)
+}
+
+func (c TypedColumn[T]) Sum() Aggregate {
+	return Sum(c.name)
+}
+
+func (c TypedColumn[T]) Count() Aggregate {
+	return Count(c.name)
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"orm/internal/errs"
+)
+
+func TestTypedColumn(t *testing.T) {
+	db := memoryDB(t)
+	cols := struct {
+		Id        TypedColumn[int64]
+		FirstName TypedColumn[string]
+		Age       TypedColumn[int8]
+	}{
+		Id:        TypedC[int64]("Id"),
+		FirstName: TypedC[string]("FirstName"),
+		Age:       TypedC[int8]("Age"),
+	}
+	testCases := []struct {
+		name      string
+		builder   QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "compare",
+			builder: NewSelector[TestModel](db).Where(cols.Age.GTEQ(18), cols.Age.LTEQ(30), cols.Id.NEQ(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE ((`age` >= ?) AND (`age` <= ?)) AND (`id` != ?);",
+				Args: []any{int8(18), int8(30), int64(1)},
+			},
+		},
+		{
+			name:    "in",
+			builder: NewSelector[TestModel](db).Where(cols.Id.In(1, 2, 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?);",
+				Args: []any{int64(1), int64(2), int64(3)},
+			},
+		},
+		{
+			name:    "empty in",
+			builder: NewSelector[TestModel](db).Where(cols.Id.In()),
+			wantErr: errs.ErrEmptySliceArg,
+		},
+		{
+			name:    "like",
+			builder: NewSelector[TestModel](db).Where(cols.FirstName.Like("T%")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` LIKE ?;",
+				Args: []any{"T%"},
+			},
+		},
+		{
+			name: "select and order by",
+			builder: NewSelector[TestModel](db).Select(cols.FirstName.Column(), cols.Age.As("my_age")).
+				OrderBy(cols.Age.Desc(), cols.Id.Asc()),
+			wantQuery: &Query{
+				SQL: "SELECT `first_name`,`age` AS `my_age` FROM `test_model` ORDER BY `age` DESC,`id` ASC;",
+			},
+		},
+		{
+			name: "aggregate",
+			builder: NewSelector[TestModel](db).Select(cols.Age.Sum(), cols.Age.Max(), cols.Id.Count().As("cnt")).
+				GroupBy(cols.FirstName.Column()),
+			wantQuery: &Query{
+				SQL: "SELECT SUM(`age`),MAX(`age`),COUNT(`id`) AS `cnt` FROM `test_model` GROUP BY `first_name`;",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
@@ -22,4 +22,6 @@ type QueryBuilder interface {
 type Query struct {
 	SQL  string
 	Args []any
+	// Comments 是语句上通过 Comment 加的注释，执行的时候才会和 ctx 上的注释一起加到 SQL 前面
+	Comments map[string]string
 }
@@ -0,0 +1,291 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"orm/internal/errs"
+)
+
+type Updater[T any] struct {
+	builder
+	val     *T
+	assigns []Assignable
+	where   []Predicate
+	table   TableReference
+}
+
+func NewUpdater[T any](sess Session) *Updater[T] {
+	core := sess.getCore()
+	return &Updater[T]{
+		builder: builder{sess: sess, core: core},
+	}
+}
+
+// Update 指定实体，Set 里面的 Column 从实体里面取值。
+// 没有调用 Set 的时候，如果实体是通过 DB.Tracked 查询出来的，只更新变化了的列，否则更新所有的列
+func (u *Updater[T]) Update(val *T) *Updater[T] {
+	u.val = val
+	return u
+}
+
+// Set 指定要更新的列，Assignment 使用它自己的值，Column 使用 Update 传入的实体的值
+func (u *Updater[T]) Set(assigns ...Assignable) *Updater[T] {
+	u.assigns = assigns
+	return u
+}
+
+func (u *Updater[T]) Where(ps ...Predicate) *Updater[T] {
+	u.where = ps
+	return u
+}
+
+// Unscoped 不使用 Scope 和多租户隔离
+func (u *Updater[T]) Unscoped() *Updater[T] {
+	u.unscoped = true
+	return u
+}
+
+// Table 指定要更新的表，可以是 Join，这时候 JOIN 最左边的表必须是 T 对应的表
+func (u *Updater[T]) Table(table TableReference) *Updater[T] {
+	u.table = table
+	return u
+}
+
+// Changes 返回 Update 传入的实体和查询出来的时候相比有哪些变化，可以用来记录审计日志
+func (u *Updater[T]) Changes() ([]Change, error) {
+	if u.val == nil {
+		return nil, errs.ErrUpdateNoEntity
+	}
+	if err := u.initModel(); err != nil {
+		return nil, err
+	}
+	if u.tracker == nil {
+		return nil, errs.ErrEntityNotTracked
+	}
+	changes, tracked, err := u.tracker.changes(u.Model, u.val, u.creator(u.Model, u.val))
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		return nil, errs.ErrEntityNotTracked
+	}
+	return changes, nil
+}
+
+func (u *Updater[T]) initModel() error {
+	if u.Model != nil {
+		return nil
+	}
+	var err error
+	u.Model, err = u.r.Get(new(T))
+	return err
+}
+
+// dirtyAssigns 返回跟踪的实体变化了的列，第二个返回值表示实体有没有被跟踪
+func (u *Updater[T]) dirtyAssigns() ([]Assignable, bool, error) {
+	if len(u.assigns) > 0 || u.val == nil || u.tracker == nil {
+		return nil, false, nil
+	}
+	changes, err := u.Changes()
+	if err == errs.ErrEntityNotTracked {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	res := make([]Assignable, 0, len(changes))
+	for _, c := range changes {
+		res = append(res, C(c.Field))
+	}
+	return res, true, nil
+}
+
+func (u *Updater[T]) Build() (*Query, error) {
+	stmt, err := u.statement()
+	if err != nil {
+		return nil, err
+	}
+	return u.render(stmt)
+}
+
+func (u *Updater[T]) statement() (*Statement, error) {
+	u = u.Clone()
+	if len(u.assigns) == 0 && u.val == nil {
+		return nil, errs.ErrNoUpdatedColumns
+	}
+	if err := u.initModel(); err != nil {
+		return nil, err
+	}
+	dirty, tracked, err := u.dirtyAssigns()
+	if err != nil {
+		return nil, err
+	}
+	if tracked && len(dirty) == 0 {
+		return nil, errs.ErrNoUpdatedColumns
+	}
+	if err = u.checkTenantAssigns(u.assigns); err != nil {
+		return nil, err
+	}
+	table, scopes, err := u.scoped("UPDATE", u, u.table)
+	if err != nil {
+		return nil, err
+	}
+	stmt := u.newStatement("UPDATE")
+	stmt.Table, stmt.Where, stmt.Scopes, stmt.Assigns = table, u.where, scopes, u.assigns
+	if len(stmt.Assigns) == 0 {
+		stmt.Assigns = dirty
+	}
+	return stmt, u.rewrite(stmt, "")
+}
+
+func (u *Updater[T]) render(stmt *Statement) (*Query, error) {
+	u = u.Clone()
+	if err := u.apply(stmt); err != nil {
+		return nil, err
+	}
+	u.table, u.assigns = stmt.Table, stmt.Assigns
+	where := stmt.predicates()
+	switch t := u.table.(type) {
+	case Join:
+		stmt, err := u.newJoinStmt(t, where)
+		if err != nil {
+			return nil, err
+		}
+		stmt.set = func(qualify bool) error {
+			return u.buildAssigns(stmt.target, qualify)
+		}
+		if err = u.dialect.buildJoinUpdate(&u.builder, stmt); err != nil {
+			return nil, err
+		}
+	case nil, Table:
+		target, _ := t.(Table)
+		if t != nil {
+			if err := u.checkTarget(target); err != nil {
+				return nil, err
+			}
+		}
+		u.sb.WriteString("UPDATE ")
+		if err := u.buildTable(u.table); err != nil {
+			return nil, err
+		}
+		u.sb.WriteString(" SET ")
+		if err := u.buildAssigns(target, false); err != nil {
+			return nil, err
+		}
+		if err := u.buildWhere(where); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errs.NewErrUnSupportedTable(t)
+	}
+	u.sb.WriteByte(';')
+	return u.build()
+}
+
+func (u *Updater[T]) buildAssigns(target Table, qualify bool) error {
+	assigns := u.assigns
+	if len(assigns) == 0 {
+		assigns = make([]Assignable, 0, len(u.Model.FieldArr))
+		for _, fd := range u.Model.FieldArr {
+			assigns = append(assigns, C(fd.GoName))
+		}
+	}
+	// 按照实体更新的时候租户字段使用当前的租户，不能挪到别的租户下面
+	tenantVal, scoped, err := u.tenantValue()
+	if err != nil {
+		return err
+	}
+	for i, assign := range assigns {
+		if i > 0 {
+			u.sb.WriteByte(',')
+		}
+		switch a := assign.(type) {
+		case Assignment:
+			if err := u.buildSetColumn(target, a.col, qualify); err != nil {
+				return err
+			}
+			val, err := assignValue(u.Model.Fields[a.col], a.val)
+			if err != nil {
+				return err
+			}
+			if err = u.buildExpression(val); err != nil {
+				return err
+			}
+		case Column:
+			if u.val == nil {
+				return errs.ErrUpdateNoEntity
+			}
+			if err := u.buildSetColumn(target, a.name, qualify); err != nil {
+				return err
+			}
+			if scoped && a.name == u.tenant.field {
+				u.sb.WriteByte('?')
+				u.addArg(tenantVal)
+				continue
+			}
+			val, err := u.creator(u.Model, u.val).Field(a.name)
+			if err != nil {
+				return err
+			}
+			u.sb.WriteByte('?')
+			u.addArg(val)
+		case JSONAssignment:
+			if err := u.buildSetColumn(target, a.col.name, qualify); err != nil {
+				return err
+			}
+			if qualify && target.alias != "" {
+				a.col = target.C(a.col.name)
+			}
+			if err := u.dialect.buildJSON(&u.builder, a); err != nil {
+				return err
+			}
+		default:
+			return errs.NewErrUnSupportAssignable(a)
+		}
+	}
+	return nil
+}
+
+func (u *Updater[T]) buildSetColumn(target Table, name string, qualify bool) error {
+	fd, ok := u.Model.Fields[name]
+	if !ok {
+		return errs.NewErrUnKnownField(name)
+	}
+	if qualify {
+		u.buildTargetName(target)
+		u.sb.WriteByte('.')
+	}
+	u.quote(fd.ColName)
+	u.sb.WriteByte('=')
+	return nil
+}
+
+// Exec 执行更新，跟踪的实体没有任何变化的时候什么都不做，RowsAffected 返回 0
+func (u *Updater[T]) Exec(ctx context.Context) Result {
+	if err := u.initModel(); err != nil {
+		return Result{err: err}
+	}
+	dirty, tracked, err := u.dirtyAssigns()
+	if err != nil {
+		return Result{err: err}
+	}
+	if tracked && len(dirty) == 0 {
+		return Result{res: driver.RowsAffected(0)}
+	}
+	res := exec[T](ctx, u.sess, u.core, &QueryContext{
+		Type:    "UPDATE",
+		Builder: u,
+		Model:   u.Model,
+	})
+	if res.Result == nil {
+		return Result{
+			err: res.Err,
+		}
+	}
+	r := res.Result.(Result)
+	if tracked && r.err == nil {
+		// 更新成功之后以当前的值作为新的快照
+		r.err = u.tracker.snapshot(u.Model, u.val, u.creator(u.Model, u.val))
+	}
+	return r
+}
@@ -0,0 +1,250 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"orm/internal/errs"
+)
+
+type Order struct {
+	Id     int64
+	UserId int64
+	Status string
+}
+
+func (Order) CreateSQL() string {
+	return `
+CREATE TABLE IF NOT EXISTS ` + "`order`" + `(
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL
+)
+`
+}
+
+func TestUpdater_Build(t *testing.T) {
+	o := TableOf(&Order{}).As("o")
+	u := TableOf(&TestModel{}).As("u")
+	testCases := []struct {
+		name      string
+		dialect   Dialect
+		builder   func(db *DB) QueryBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "no columns",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db)
+			},
+			wantErr: errs.ErrNoUpdatedColumns,
+		},
+		{
+			name:    "assign",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Set(Assign("Age", 18), Assign("FirstName", "Tom")).
+					Where(C("Id").EQ(1))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `age`=?,`first_name`=? WHERE `id` = ?;",
+				Args: []any{18, "Tom", 1},
+			},
+		},
+		{
+			name:    "entity",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Update(&TestModel{Id: 1, FirstName: "Tom", Age: 18}).
+					Set(C("FirstName"), Assign("Age", C("Id")))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `first_name`=?,`age`=`id`;",
+				Args: []any{"Tom"},
+			},
+		},
+		{
+			name:    "all columns",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Update(&TestModel{Id: 1, FirstName: "Tom", Age: 18}).
+					Where(C("Id").EQ(1))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `id`=?,`first_name`=?,`age`=?,`last_name`=? WHERE `id` = ?;",
+				Args: []any{int64(1), "Tom", int8(18), sql.NullString{}, 1},
+			},
+		},
+		{
+			name:    "column without entity",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Set(C("FirstName"))
+			},
+			wantErr: errs.ErrUpdateNoEntity,
+		},
+		{
+			name:    "unknown field",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Set(Assign("Invalid", 1))
+			},
+			wantErr: errs.NewErrUnKnownField("Invalid"),
+		},
+		{
+			name:    "table alias",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Table(u).Set(Assign("Age", 18)).Where(u.C("Id").EQ(1))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` AS `u` SET `age`=? WHERE `u`.`id` = ?;",
+				Args: []any{18, 1},
+			},
+		},
+		{
+			name:    "another table",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[TestModel](db).Table(o).Set(Assign("Age", 18))
+			},
+			wantErr: errs.ErrJoinTarget,
+		},
+		{
+			name:    "mysql join",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[Order](db).Table(o.Join(u).On(o.C("UserId").EQ(u.C("Id")))).
+					Set(Assign("Status", u.C("FirstName"))).Where(u.C("Age").LT(18))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `order` AS `o` JOIN `test_model` AS `u` ON `o`.`user_id` = `u`.`id` SET `o`.`status`=`u`.`first_name` WHERE `u`.`age` < ?;",
+				Args: []any{18},
+			},
+		},
+		{
+			name:    "postgres join",
+			dialect: DialectPostgreSQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[Order](db).Table(o.Join(u).On(o.C("UserId").EQ(u.C("Id")))).
+					Set(Assign("Status", "closed")).Where(u.C("Age").LT(18))
+			},
+			wantQuery: &Query{
+				SQL:  `UPDATE "order" AS "o" SET "status"=? FROM "test_model" AS "u" WHERE ("o"."user_id" = "u"."id") AND ("u"."age" < ?);`,
+				Args: []any{"closed", 18},
+			},
+		},
+		{
+			name:    "sqlite multiple join",
+			dialect: DialectSQLite,
+			builder: func(db *DB) QueryBuilder {
+				d := TableOf(&TestModel{}).As("d")
+				return NewUpdater[Order](db).Table(o.Join(u).On(o.C("UserId").EQ(u.C("Id"))).
+					LeftJoin(d).On(d.C("Id").EQ(u.C("Age")))).
+					Set(Assign("Status", "closed"))
+			},
+			wantQuery: &Query{
+				SQL:  "UPDATE `order` AS `o` SET `status`=? FROM (`test_model` AS `u` LEFT JOIN `test_model` AS `d` ON `d`.`id` = `u`.`age`) WHERE `o`.`user_id` = `u`.`id`;",
+				Args: []any{"closed"},
+			},
+		},
+		{
+			name:    "postgres left join",
+			dialect: DialectPostgreSQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[Order](db).Table(o.LeftJoin(u).On(o.C("UserId").EQ(u.C("Id")))).
+					Set(Assign("Status", "closed"))
+			},
+			wantErr: errs.NewErrUnSupportedJoin("LEFT JOIN"),
+		},
+		{
+			name:    "join target",
+			dialect: DialectMySQL,
+			builder: func(db *DB) QueryBuilder {
+				return NewUpdater[Order](db).Table(u.Join(o).On(o.C("UserId").EQ(u.C("Id")))).
+					Set(Assign("Status", "closed"))
+			},
+			wantErr: errs.ErrJoinTarget,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := memoryDB(t, DBWithDialect(tc.dialect))
+			q, err := tc.builder(db).Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestUpdater_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	db, err := OpenDB(mockDB)
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE `test_model` SET `age`=\\? WHERE `id` = \\?;").WithArgs(18, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnError(errors.New("db error"))
+
+	affected, err := NewUpdater[TestModel](db).Set(Assign("Age", 18)).Where(C("Id").EQ(1)).
+		Exec(context.Background()).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	res := NewUpdater[TestModel](db).Set(Assign("Age", 18)).Exec(context.Background())
+	assert.Equal(t, errors.New("db error"), res.Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateAndDeleteJoin_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:update_join.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec(TestModel{}.CreateSQL())
+	require.NoError(t, err)
+	_, err = db.db.Exec(Order{}.CreateSQL())
+	require.NoError(t, err)
+	ctx := context.Background()
+	users := []*TestModel{
+		{Id: 1, FirstName: "Tom", Age: 10, LastName: sql.NullString{String: "Cat", Valid: true}},
+		{Id: 2, FirstName: "Jerry", Age: 20, LastName: sql.NullString{String: "Mouse", Valid: true}},
+	}
+	require.NoError(t, NewInserter[TestModel](db).Values(users...).Exec(ctx).Err())
+	orders := []*Order{{Id: 1, UserId: 1, Status: "new"}, {Id: 2, UserId: 2, Status: "new"}, {Id: 3, UserId: 1, Status: "new"}}
+	require.NoError(t, NewInserter[Order](db).Values(orders...).Exec(ctx).Err())
+
+	o := TableOf(&Order{}).As("o")
+	u := TableOf(&TestModel{}).As("u")
+	affected, err := NewUpdater[Order](db).Table(o.Join(u).On(o.C("UserId").EQ(u.C("Id")))).
+		Set(Assign("Status", u.C("FirstName"))).Where(u.C("Age").LT(18)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	res, err := NewSelector[Order](db).Where(C("Id").EQ(3)).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Tom", res.Status)
+
+	affected, err = NewDeleter[Order](db).Table(o.Join(u).On(o.C("UserId").EQ(u.C("Id")))).
+		Where(u.C("Age").GT(18)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	_, err = NewSelector[Order](db).Where(C("Id").EQ(2)).Get(ctx)
+	assert.Equal(t, ErrNoRows, err)
+
+	// 把剩下的订单复制一份
+	affected, err = NewInserter[Order](db).Columns("UserId", "Status").
+		ValuesFrom(NewSelector[Order](db).Select(C("UserId"), C("Status"))).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+}
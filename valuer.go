@@ -0,0 +1,18 @@
+package orm
+
+import (
+	"orm/internal/valuer"
+	"orm/model"
+	"reflect"
+)
+
+// Value 读写实体的字段，生成的代码实现这个接口来避免反射
+type Value = valuer.Value
+
+// RegisterValuer 注册 T 的 Value 构造函数，之后所有 DB 都会优先使用它。
+// 一般由 gen 生成的代码在 init 里面调用
+func RegisterValuer[T any](fn func(m *model.Model, val *T) Value) {
+	valuer.Register(reflect.TypeOf(new(T)), func(m *model.Model, entity any) valuer.Value {
+		return fn(m, entity.(*T))
+	})
+}